@@ -0,0 +1,243 @@
+package cache
+
+import (
+	// Native packages
+	"context"
+	"math/rand"
+	"time"
+
+	// Third parties
+	"golang.org/x/sync/singleflight"
+
+	// Internal packages
+	"github.com/tinwoan-go/basic-api/redis"
+	"github.com/tinwoan-go/basic-api/tlog"
+)
+
+var (
+	// defaultCache is used by the
+	// package-level helpers, with the
+	// JSON codec until NewCache is
+	// called.
+	defaultCache = New(Configs{})
+
+	log tlog.Logger
+
+	// ErrNotFound is returned by Get
+	// when the key is not cached.
+	ErrNotFound = redis.ErrNotFound
+)
+
+func init() {
+	log = tlog.WithPrefix("cache")
+}
+
+// Loader loads the value of a key
+// missing from the cache, typically
+// from Mongo or SQL.
+type Loader func(ctx context.Context) (interface{}, error)
+
+// Configs contains the configuration
+// of the cache.
+type Configs struct {
+	// Codec encodes the cached values.
+	// Nil means JSON.
+	Codec Codec
+	// Jitter randomizes the TTL of the
+	// cached values by up to this ratio
+	// (e.g. 0.1 for +/-10%), so that keys
+	// cached at the same time do not
+	// expire at the same time.
+	Jitter float64
+	// Prefix is prepended to every key
+	// stored in Redis.
+	Prefix string
+	// LoadTimeout bounds the shared
+	// call of a loader. Zero means
+	// 30 seconds.
+	LoadTimeout time.Duration
+}
+
+// Cache stores values in Redis
+// with a codec. The connection to
+// Redis must be opened with
+// redis.NewRedisClient.
+type Cache struct {
+	codec       Codec
+	jitter      float64
+	prefix      string
+	loadTimeout time.Duration
+	group       singleflight.Group
+}
+
+// New creates a cache based on
+// the given configuration.
+func New(cfg Configs) *Cache {
+	c := &Cache{codec: cfg.Codec, jitter: cfg.Jitter, prefix: cfg.Prefix, loadTimeout: cfg.LoadTimeout}
+	if c.codec == nil {
+		c.codec = JSON
+	}
+	if c.loadTimeout <= 0 {
+		c.loadTimeout = 30 * time.Second
+	}
+	return c
+}
+
+// NewCache creates the cache used
+// by the package-level helpers
+// based on the given configuration.
+func NewCache(cfg Configs) {
+	defaultCache = New(cfg)
+}
+
+// GetOrLoad decodes the value cached
+// under 'key' into 'dest'. On a miss,
+// 'loader' is called and its result
+// is cached for 'ttl' and decoded
+// into 'dest'. Concurrent misses of
+// the same key call 'loader' only
+// once and share its result.
+func GetOrLoad(ctx context.Context, key string, ttl time.Duration, dest interface{}, loader Loader) error {
+	return defaultCache.GetOrLoad(ctx, key, ttl, dest, loader)
+}
+
+// Get decodes the value cached under
+// 'key' into 'dest', or returns
+// ErrNotFound.
+func Get(key string, dest interface{}) error {
+	return defaultCache.Get(key, dest)
+}
+
+// Set caches the value under
+// 'key' for 'ttl'.
+func Set(key string, value interface{}, ttl time.Duration) error {
+	return defaultCache.Set(key, value, ttl)
+}
+
+// Delete removes the values
+// cached under the keys.
+func Delete(keys ...string) error {
+	return defaultCache.Delete(keys...)
+}
+
+// GetOrLoad decodes the value cached
+// under 'key' into 'dest', or loads,
+// caches and decodes it on a miss.
+// When Redis fails, the error is
+// logged and the value is loaded.
+// The shared call of the loader runs
+// with the values of the context of
+// the first caller (e.g. request ID),
+// but is not canceled with it, so
+// that the other callers still get
+// the value. It is bounded by the
+// LoadTimeout instead.
+func (c *Cache) GetOrLoad(ctx context.Context, key string, ttl time.Duration, dest interface{}, loader Loader) error {
+	data, err := redis.GetBytes(c.prefix + key)
+	switch {
+	case err == nil:
+		if err = c.codec.Unmarshal(data, dest); err == nil {
+			return nil
+		}
+		log.TWarnf(ctx, "Can not decode the cached value of %s, error: %v", key, err)
+	case err != redis.ErrNotFound:
+		log.TWarnf(ctx, "Can not get the cached value of %s, error: %v", key, err)
+	}
+
+	ch := c.group.DoChan(key, func() (interface{}, error) {
+		ctx, cancel := context.WithTimeout(detached{ctx}, c.loadTimeout)
+		defer cancel()
+		value, err := loader(ctx)
+		if err != nil {
+			return nil, err
+		}
+		data, err := c.codec.Marshal(value)
+		if err != nil {
+			return nil, err
+		}
+		if _, err := redis.Set(c.prefix+key, data, c.ttl(ttl)); err != nil {
+			log.TWarnf(ctx, "Can not cache the value of %s, error: %v", key, err)
+		}
+		return data, nil
+	})
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case res := <-ch:
+		if res.Err != nil {
+			return res.Err
+		}
+		return c.codec.Unmarshal(res.Val.([]byte), dest)
+	}
+}
+
+// Get decodes the value cached under
+// 'key' into 'dest', or returns
+// ErrNotFound.
+func (c *Cache) Get(key string, dest interface{}) error {
+	data, err := redis.GetBytes(c.prefix + key)
+	if err != nil {
+		return err
+	}
+	return c.codec.Unmarshal(data, dest)
+}
+
+// Set caches the value under 'key'
+// for 'ttl', with the jitter.
+func (c *Cache) Set(key string, value interface{}, ttl time.Duration) error {
+	data, err := c.codec.Marshal(value)
+	if err != nil {
+		return err
+	}
+	_, err = redis.Set(c.prefix+key, data, c.ttl(ttl))
+	return err
+}
+
+// Delete removes the values
+// cached under the keys.
+func (c *Cache) Delete(keys ...string) error {
+	prefixed := make([]string, len(keys))
+	for i, key := range keys {
+		prefixed[i] = c.prefix + key
+	}
+	_, err := redis.Del(prefixed...)
+	return err
+}
+
+// detached keeps the values of the
+// context, but neither its deadline
+// nor its cancellation.
+type detached struct {
+	parent context.Context
+}
+
+func (detached) Deadline() (time.Time, bool) {
+	return time.Time{}, false
+}
+
+func (detached) Done() <-chan struct{} {
+	return nil
+}
+
+func (detached) Err() error {
+	return nil
+}
+
+func (d detached) Value(key interface{}) interface{} {
+	return d.parent.Value(key)
+}
+
+// ttl returns 'ttl' randomized
+// by the jitter of the cache.
+func (c *Cache) ttl(ttl time.Duration) time.Duration {
+	if c.jitter <= 0 || ttl <= 0 {
+		return ttl
+	}
+	delta := time.Duration((rand.Float64()*2 - 1) * c.jitter * float64(ttl))
+	if ttl+delta <= 0 {
+		return ttl
+	}
+	return ttl + delta
+}
+
+// End-of-file
@@ -0,0 +1,72 @@
+package cache
+
+import (
+	// Native packages
+	"bytes"
+	"encoding/gob"
+	"encoding/json"
+
+	// Third parties
+	"github.com/vmihailenco/msgpack"
+)
+
+// Codec encodes the values stored
+// in the cache and decodes them back.
+type Codec interface {
+	Marshal(v interface{}) ([]byte, error)
+	Unmarshal(data []byte, v interface{}) error
+}
+
+var (
+	// JSON encodes the values with
+	// encoding/json. It is the default
+	// codec of the cache.
+	JSON Codec = jsonCodec{}
+
+	// Msgpack encodes the values with
+	// MessagePack, which is smaller and
+	// faster than JSON.
+	Msgpack Codec = msgpackCodec{}
+
+	// Gob encodes the values with
+	// encoding/gob. The concrete types
+	// stored in interfaces must be
+	// registered with gob.Register.
+	Gob Codec = gobCodec{}
+)
+
+type jsonCodec struct{}
+
+func (jsonCodec) Marshal(v interface{}) ([]byte, error) {
+	return json.Marshal(v)
+}
+
+func (jsonCodec) Unmarshal(data []byte, v interface{}) error {
+	return json.Unmarshal(data, v)
+}
+
+type msgpackCodec struct{}
+
+func (msgpackCodec) Marshal(v interface{}) ([]byte, error) {
+	return msgpack.Marshal(v)
+}
+
+func (msgpackCodec) Unmarshal(data []byte, v interface{}) error {
+	return msgpack.Unmarshal(data, v)
+}
+
+type gobCodec struct{}
+
+func (gobCodec) Marshal(v interface{}) ([]byte, error) {
+	var buf bytes.Buffer
+	if err := gob.NewEncoder(&buf).Encode(v); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+func (gobCodec) Unmarshal(data []byte, v interface{}) error {
+	return gob.NewDecoder(bytes.NewReader(data)).Decode(v)
+}
+
+// End-of-file
@@ -0,0 +1,207 @@
+package handler
+
+import (
+	// Native packages
+	"math"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	// Third parties
+	"github.com/go-chi/chi"
+	"github.com/go-chi/render"
+
+	// Internal packages
+	"github.com/tinwoan-go/basic-api/redis"
+	"github.com/tinwoan-go/basic-api/tlog"
+)
+
+// KeyFunc returns the key whose
+// requests are counted together
+// by the rate limiter.
+type KeyFunc func(r *http.Request) string
+
+// KeyByIP counts the requests by
+// client IP. Use the RealIP
+// middleware of chi behind a
+// proxy.
+func KeyByIP(r *http.Request) string {
+	if host, _, err := net.SplitHostPort(r.RemoteAddr); err == nil {
+		return host
+	}
+	return r.RemoteAddr
+}
+
+// KeyByHeader counts the requests
+// by the value of the header
+// (e.g. an API key), or by client
+// IP when the header is missing.
+func KeyByHeader(name string) KeyFunc {
+	return func(r *http.Request) string {
+		if value := r.Header.Get(name); value != "" {
+			return value
+		}
+		return KeyByIP(r)
+	}
+}
+
+// KeyByRoute counts the requests
+// by method and route pattern (or
+// path when the request has not
+// been routed yet).
+func KeyByRoute(r *http.Request) string {
+	route := r.URL.Path
+	if rctx := chi.RouteContext(r.Context()); rctx != nil && rctx.RoutePattern() != "" {
+		route = rctx.RoutePattern()
+	}
+	return r.Method + " " + route
+}
+
+// Keys combines the keys, e.g.
+// Keys(KeyByIP, KeyByRoute) for
+// a limit per client and route.
+func Keys(fns ...KeyFunc) KeyFunc {
+	return func(r *http.Request) string {
+		keys := make([]string, len(fns))
+		for i, fn := range fns {
+			keys[i] = fn(r)
+		}
+		return strings.Join(keys, "|")
+	}
+}
+
+// RateLimitOptions contains the
+// options of the rate limiter.
+type RateLimitOptions struct {
+	// Limit is the maximum number
+	// of requests per key in the
+	// sliding Window. Both must be
+	// positive, the Window being
+	// counted in milliseconds.
+	Limit  int64
+	Window time.Duration
+	// Key is the key of the
+	// requests. Nil means KeyByIP.
+	Key KeyFunc
+	// Prefix is prepended to the
+	// keys stored in Redis. Empty
+	// means "ratelimit:".
+	Prefix string
+}
+
+// NewRateLimitMiddleware limits the
+// number of requests per key with a
+// sliding window. The counters are
+// stored in Redis when the connection
+// has been opened, so that the limit
+// holds across the replicas, and in
+// memory otherwise. The requests over
+// the limit are answered with 429 and
+// a Retry-After header. When Redis
+// fails, the requests are allowed.
+// It panics when the Limit or the
+// Window is not positive.
+func NewRateLimitMiddleware(opts RateLimitOptions) func(http.Handler) http.Handler {
+	if opts.Limit <= 0 || opts.Window < time.Millisecond {
+		panic(redis.ErrInvalidRateLimit)
+	}
+	if opts.Key == nil {
+		opts.Key = KeyByIP
+	}
+	if opts.Prefix == "" {
+		opts.Prefix = "ratelimit:"
+	}
+	local := newMemoryLimiter()
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			key := opts.Prefix + opts.Key(r)
+			var limit redis.RateLimit
+			if redis.Available() {
+				var err error
+				if limit, err = redis.SlidingWindow(key, opts.Limit, opts.Window); err != nil {
+					tlog.TWarnf(r.Context(), "Can not check the rate limit of %s, error: %v", key, err)
+					next.ServeHTTP(w, r)
+					return
+				}
+			} else {
+				limit = local.hit(key, opts.Limit, opts.Window)
+			}
+
+			reset := seconds(limit.Reset)
+			w.Header().Set("X-RateLimit-Limit", strconv.FormatInt(opts.Limit, 10))
+			w.Header().Set("X-RateLimit-Remaining", strconv.FormatInt(limit.Remaining, 10))
+			w.Header().Set("X-RateLimit-Reset", strconv.FormatInt(time.Now().Unix()+reset, 10))
+			if !limit.Allowed {
+				w.Header().Set("Retry-After", strconv.FormatInt(reset, 10))
+				render.Status(r, http.StatusTooManyRequests)
+				render.JSON(w, r, struct {
+					Status string `json:"status"`
+				}{
+					Status: "TOO_MANY_REQUESTS",
+				})
+				return
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// seconds rounds 'd' up
+// to whole seconds.
+func seconds(d time.Duration) int64 {
+	return int64(math.Ceil(d.Seconds()))
+}
+
+// memoryLimiter is the in-memory
+// sliding window of the rate
+// limiter, used without Redis.
+type memoryLimiter struct {
+	mux       sync.Mutex
+	hits      map[string][]time.Time
+	lastSweep time.Time
+}
+
+func newMemoryLimiter() *memoryLimiter {
+	return &memoryLimiter{hits: map[string][]time.Time{}, lastSweep: time.Now()}
+}
+
+func (l *memoryLimiter) hit(key string, limit int64, window time.Duration) redis.RateLimit {
+	l.mux.Lock()
+	defer l.mux.Unlock()
+
+	now := time.Now()
+	// Forget the keys without
+	// hits in the last window.
+	if now.Sub(l.lastSweep) >= window {
+		for k, hits := range l.hits {
+			if len(hits) == 0 || now.Sub(hits[len(hits)-1]) >= window {
+				delete(l.hits, k)
+			}
+		}
+		l.lastSweep = now
+	}
+
+	hits := l.hits[key]
+	i := 0
+	for i < len(hits) && now.Sub(hits[i]) >= window {
+		i++
+	}
+	hits = hits[i:]
+	allowed := int64(len(hits)) < limit
+	if allowed {
+		hits = append(hits, now)
+	}
+	l.hits[key] = hits
+
+	reset := window
+	if len(hits) > 0 {
+		reset = hits[0].Add(window).Sub(now)
+	}
+	return redis.RateLimit{Allowed: allowed, Remaining: limit - int64(len(hits)), Reset: reset}
+}
+
+// End-of-file
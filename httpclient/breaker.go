@@ -0,0 +1,235 @@
+package httpclient
+
+import (
+	// Native packages
+	"errors"
+	"sync"
+	"time"
+)
+
+// ErrCircuitOpen is returned without
+// sending the request when the circuit
+// breaker of the host is open.
+var ErrCircuitOpen = errors.New("circuit breaker is open")
+
+// BreakerPolicy contains the options
+// of the circuit breakers, one per
+// host. A breaker opens when the ratio
+// of failed requests (connection errors
+// and 5xx statuses) reaches the
+// FailureRatio, and the requests to the
+// host fail fast until the CoolDown is
+// over. Then a few trial requests are
+// sent (half-open): the breaker closes
+// when they succeed, and opens again
+// otherwise.
+type BreakerPolicy struct {
+	// FailureRatio is the ratio of
+	// failed requests from which the
+	// breaker opens. Zero disables
+	// the circuit breakers.
+	FailureRatio float64
+	// MinRequests is the minimum number
+	// of requests in the Window before
+	// the ratio is considered.
+	MinRequests int
+	// Window is the period over which
+	// the requests are counted.
+	Window time.Duration
+	// CoolDown is the time the breaker
+	// stays open.
+	CoolDown time.Duration
+	// HalfOpenRequests is the number of
+	// trial requests sent concurrently
+	// when half-open.
+	HalfOpenRequests int
+}
+
+// DefaultBreakerPolicy is the policy
+// used until SetBreakerPolicy is called.
+var DefaultBreakerPolicy = BreakerPolicy{
+	FailureRatio:     0.5,
+	MinRequests:      20,
+	Window:           30 * time.Second,
+	CoolDown:         30 * time.Second,
+	HalfOpenRequests: 1,
+}
+
+type breakerState int
+
+const (
+	closed breakerState = iota
+	open
+	halfOpen
+)
+
+func (s breakerState) String() string {
+	switch s {
+	case open:
+		return "open"
+	case halfOpen:
+		return "half-open"
+	default:
+		return "closed"
+	}
+}
+
+// breaker is the circuit
+// breaker of a host.
+type breaker struct {
+	host   string
+	policy BreakerPolicy
+
+	mux         sync.Mutex
+	state       breakerState
+	windowStart time.Time
+	requests    int
+	failures    int
+	openedAt    time.Time
+	trials      int
+	// generation is incremented on
+	// every change of state, so that
+	// the results of the requests
+	// allowed in a previous state
+	// are ignored.
+	generation uint64
+}
+
+var (
+	breakerPolicy = DefaultBreakerPolicy
+	breakers      = map[string]*breaker{}
+	breakersMux   = &sync.Mutex{}
+)
+
+// SetBreakerPolicy sets the policy
+// of the circuit breakers, and
+// resets their states. The fields
+// which are not positive, other
+// than FailureRatio, take the values
+// of DefaultBreakerPolicy.
+func SetBreakerPolicy(policy BreakerPolicy) {
+	if policy.MinRequests <= 0 {
+		policy.MinRequests = DefaultBreakerPolicy.MinRequests
+	}
+	if policy.Window <= 0 {
+		policy.Window = DefaultBreakerPolicy.Window
+	}
+	if policy.CoolDown <= 0 {
+		policy.CoolDown = DefaultBreakerPolicy.CoolDown
+	}
+	if policy.HalfOpenRequests <= 0 {
+		policy.HalfOpenRequests = DefaultBreakerPolicy.HalfOpenRequests
+	}
+	breakersMux.Lock()
+	breakerPolicy = policy
+	breakers = map[string]*breaker{}
+	breakersMux.Unlock()
+}
+
+// breakerOf returns the circuit
+// breaker of the host, or nil when
+// the breakers are disabled.
+func breakerOf(host string) *breaker {
+	breakersMux.Lock()
+	defer breakersMux.Unlock()
+	if breakerPolicy.FailureRatio <= 0 {
+		return nil
+	}
+	b, ok := breakers[host]
+	if !ok {
+		b = &breaker{host: host, policy: breakerPolicy, windowStart: time.Now()}
+		breakers[host] = b
+	}
+	return b
+}
+
+// allow returns ErrCircuitOpen when
+// the request must not be sent, and
+// the generation of the breaker to
+// pass to record or release.
+func (b *breaker) allow() (uint64, error) {
+	b.mux.Lock()
+	defer b.mux.Unlock()
+	switch b.state {
+	case open:
+		if time.Since(b.openedAt) < b.policy.CoolDown {
+			return 0, ErrCircuitOpen
+		}
+		b.setState(halfOpen)
+		fallthrough
+	case halfOpen:
+		if b.trials >= b.policy.HalfOpenRequests {
+			return 0, ErrCircuitOpen
+		}
+		b.trials++
+	}
+	return b.generation, nil
+}
+
+// record records the result of
+// a request allowed by the breaker
+// in the 'generation'. The results
+// of an older generation are
+// ignored, e.g. of the requests sent
+// before the breaker opened, which
+// are not trials of the half-open
+// breaker.
+func (b *breaker) record(generation uint64, success bool) {
+	b.mux.Lock()
+	defer b.mux.Unlock()
+	if generation != b.generation {
+		return
+	}
+	switch b.state {
+	case halfOpen:
+		b.trials--
+		if success {
+			b.setState(closed)
+		} else {
+			b.setState(open)
+		}
+	case closed:
+		if time.Since(b.windowStart) >= b.policy.Window {
+			b.windowStart, b.requests, b.failures = time.Now(), 0, 0
+		}
+		b.requests++
+		if !success {
+			b.failures++
+		}
+		if b.requests >= b.policy.MinRequests && float64(b.failures) >= b.policy.FailureRatio*float64(b.requests) {
+			b.setState(open)
+		}
+	}
+}
+
+// release releases a request allowed
+// by the breaker in the 'generation'
+// without result, e.g. canceled by
+// the caller.
+func (b *breaker) release(generation uint64) {
+	b.mux.Lock()
+	if generation == b.generation && b.state == halfOpen {
+		b.trials--
+	}
+	b.mux.Unlock()
+}
+
+func (b *breaker) setState(state breakerState) {
+	if b.state == state {
+		return
+	}
+	log.Warnf("Circuit breaker of %s changed from %s to %s", b.host, b.state, state)
+	b.state = state
+	b.generation++
+	switch state {
+	case open:
+		b.openedAt = time.Now()
+	case closed:
+		b.windowStart, b.requests, b.failures = time.Now(), 0, 0
+	}
+	if state != halfOpen {
+		b.trials = 0
+	}
+}
+
+// End-of-file
@@ -0,0 +1,133 @@
+package httpclient
+
+import (
+	// Native packages
+	"testing"
+	"time"
+)
+
+func newTestBreaker() *breaker {
+	return &breaker{
+		host: "example.com",
+		policy: BreakerPolicy{
+			FailureRatio:     0.5,
+			MinRequests:      2,
+			Window:           time.Minute,
+			CoolDown:         10 * time.Millisecond,
+			HalfOpenRequests: 1,
+		},
+		windowStart: time.Now(),
+	}
+}
+
+func mustAllow(t *testing.T, b *breaker) uint64 {
+	t.Helper()
+	generation, err := b.allow()
+	if err != nil {
+		t.Fatalf("allow() error = %v, want nil", err)
+	}
+	return generation
+}
+
+func TestBreakerOpensOnFailureRatio(t *testing.T) {
+	b := newTestBreaker()
+	b.record(mustAllow(t, b), true)
+	if b.state != closed {
+		t.Fatalf("state = %s, want closed", b.state)
+	}
+	b.record(mustAllow(t, b), false)
+	if b.state != open {
+		t.Fatalf("state = %s, want open", b.state)
+	}
+	if _, err := b.allow(); err != ErrCircuitOpen {
+		t.Fatalf("allow() error = %v, want ErrCircuitOpen", err)
+	}
+}
+
+func TestBreakerHalfOpen(t *testing.T) {
+	b := newTestBreaker()
+	stale := mustAllow(t, b)
+	b.record(mustAllow(t, b), false)
+	b.record(mustAllow(t, b), false)
+	if b.state != open {
+		t.Fatalf("state = %s, want open", b.state)
+	}
+	time.Sleep(b.policy.CoolDown)
+
+	trial := mustAllow(t, b)
+	if b.state != halfOpen {
+		t.Fatalf("state = %s, want half-open", b.state)
+	}
+	if _, err := b.allow(); err != ErrCircuitOpen {
+		t.Fatalf("allow() error = %v, want ErrCircuitOpen", err)
+	}
+	// The result of a request sent
+	// before the breaker opened is
+	// not a trial.
+	b.record(stale, true)
+	if b.state != halfOpen || b.trials != 1 {
+		t.Fatalf("state = %s with %d trials, want half-open with 1 trial", b.state, b.trials)
+	}
+	b.record(trial, true)
+	if b.state != closed {
+		t.Fatalf("state = %s, want closed", b.state)
+	}
+}
+
+func TestBreakerReopensOnFailedTrial(t *testing.T) {
+	b := newTestBreaker()
+	b.record(mustAllow(t, b), false)
+	b.record(mustAllow(t, b), false)
+	time.Sleep(b.policy.CoolDown)
+
+	b.record(mustAllow(t, b), false)
+	if b.state != open {
+		t.Fatalf("state = %s, want open", b.state)
+	}
+	if _, err := b.allow(); err != ErrCircuitOpen {
+		t.Fatalf("allow() error = %v, want ErrCircuitOpen", err)
+	}
+}
+
+func TestBreakerRelease(t *testing.T) {
+	b := newTestBreaker()
+	b.record(mustAllow(t, b), false)
+	b.record(mustAllow(t, b), false)
+	time.Sleep(b.policy.CoolDown)
+
+	b.release(mustAllow(t, b))
+	if b.state != halfOpen {
+		t.Fatalf("state = %s, want half-open", b.state)
+	}
+	b.record(mustAllow(t, b), true)
+	if b.state != closed {
+		t.Fatalf("state = %s, want closed", b.state)
+	}
+}
+
+func TestSetBreakerPolicyZeroFields(t *testing.T) {
+	defer SetBreakerPolicy(DefaultBreakerPolicy)
+	SetBreakerPolicy(BreakerPolicy{FailureRatio: 0.5, CoolDown: time.Minute})
+
+	b := breakerOf("example.com")
+	want := DefaultBreakerPolicy
+	want.CoolDown = time.Minute
+	if b.policy != want {
+		t.Fatalf("policy = %+v, want %+v", b.policy, want)
+	}
+	b.record(mustAllow(t, b), false)
+	if b.state != closed {
+		t.Fatalf("state = %s after one failure, want closed", b.state)
+	}
+
+	// The half-open breaker lets
+	// a trial through.
+	b.setState(open)
+	b.openedAt = time.Now().Add(-b.policy.CoolDown)
+	b.record(mustAllow(t, b), true)
+	if b.state != closed {
+		t.Fatalf("state = %s, want closed", b.state)
+	}
+}
+
+// End-of-file
@@ -32,23 +32,45 @@ type (
 	// information for doing
 	// a POST request.
 	PostInfo struct {
+		// Ctx cancels the request when
+		// it is done, and its request ID
+		// is forwarded as X-Request-ID.
+		// Nil means no cancellation.
 		Ctx      context.Context
 		URL      string
 		Username string
 		Password string
-		Request  interface{}
-		Response interface{}
+		// IdempotencyKey is sent in the
+		// header of the retry policy and
+		// makes the request retryable.
+		IdempotencyKey string
+		Request        interface{}
+		Response       interface{}
+		// ErrorResponse receives the
+		// decoded body of a non-2xx
+		// response, returned with
+		// an *HTTPError.
+		ErrorResponse interface{}
 	}
 
 	// GetInfo contains the
 	// information for doing
 	// a GET request.
 	GetInfo struct {
+		// Ctx cancels the request when
+		// it is done, and its request ID
+		// is forwarded as X-Request-ID.
+		// Nil means no cancellation.
 		Ctx      context.Context
 		URL      string
 		Username string
 		Password string
 		Response interface{}
+		// ErrorResponse receives the
+		// decoded body of a non-2xx
+		// response, returned with
+		// an *HTTPError.
+		ErrorResponse interface{}
 	}
 )
 
@@ -139,6 +161,8 @@ func GetXML(getInfo *GetInfo) error {
 // uses 'client' to do the request
 // and bases on the Content-Type
 // to parse result in to the response.
+// A non-2xx response is returned
+// as an *HTTPError.
 func post(postInfo *PostInfo, ct string) error {
 	var (
 		b   []byte
@@ -158,28 +182,29 @@ func post(postInfo *PostInfo, ct string) error {
 	default:
 		return ErrUnsupportedContentType
 	}
-	req, err := http.NewRequest(http.MethodPost, postInfo.URL, bytes.NewBuffer(b))
-	if err != nil {
-		return err
-	}
-	req.Header.Set(contentType, ct)
-	if user, pass := postInfo.Username, postInfo.Password; user != "" && pass != "" {
-		req.SetBasicAuth(user, pass)
-	}
-	res, err := client.Do(req)
+	ctx := ctxOf(postInfo.Ctx)
+	res, err := roundTrip(ctx, ct, postInfo.ErrorResponse, func() (*http.Request, error) {
+		req, err := http.NewRequest(http.MethodPost, postInfo.URL, bytes.NewBuffer(b))
+		if err != nil {
+			return nil, err
+		}
+		req.Header.Set(contentType, ct)
+		if user, pass := postInfo.Username, postInfo.Password; user != "" && pass != "" {
+			req.SetBasicAuth(user, pass)
+		}
+		if key, header := postInfo.IdempotencyKey, retryPolicy.IdempotencyKeyHeader; key != "" && header != "" {
+			req.Header.Set(header, key)
+		}
+		return req, nil
+	})
 	if err != nil {
 		return err
 	}
-	defer func() {
-		if err := res.Body.Close(); err != nil {
-			log.TErrorf(postInfo.Ctx, "Error when close response body, error: %v", err)
-		}
-	}()
 	switch ct {
 	case jsonContentType:
-		return json.NewDecoder(res.Body).Decode(&postInfo.Response)
+		return json.Unmarshal(res.Body, &postInfo.Response)
 	case xmlContentType:
-		return xml.NewDecoder(res.Body).Decode(&postInfo.Response)
+		return xml.Unmarshal(res.Body, &postInfo.Response)
 	default:
 		return ErrUnsupportedContentType
 	}
@@ -190,29 +215,29 @@ func post(postInfo *PostInfo, ct string) error {
 // uses 'client' to do the request
 // and bases on the Content-Type
 // to parse result in to the response.
+// A non-2xx response is returned
+// as an *HTTPError.
 func get(getInfo *GetInfo, ct string) error {
-	req, err := http.NewRequest(http.MethodGet, getInfo.URL, nil)
-	if err != nil {
-		return err
-	}
-	req.Header.Set(contentType, ct)
-	if user, pass := getInfo.Username, getInfo.Password; user != "" && pass != "" {
-		req.SetBasicAuth(user, pass)
-	}
-	res, err := client.Do(req)
+	ctx := ctxOf(getInfo.Ctx)
+	res, err := roundTrip(ctx, ct, getInfo.ErrorResponse, func() (*http.Request, error) {
+		req, err := http.NewRequest(http.MethodGet, getInfo.URL, nil)
+		if err != nil {
+			return nil, err
+		}
+		req.Header.Set(contentType, ct)
+		if user, pass := getInfo.Username, getInfo.Password; user != "" && pass != "" {
+			req.SetBasicAuth(user, pass)
+		}
+		return req, nil
+	})
 	if err != nil {
 		return err
 	}
-	defer func() {
-		if err := res.Body.Close(); err != nil {
-			log.TErrorf(getInfo.Ctx, "Error when close response body, error: %v", err)
-		}
-	}()
 	switch ct {
 	case jsonContentType:
-		return json.NewDecoder(res.Body).Decode(&getInfo.Response)
+		return json.Unmarshal(res.Body, &getInfo.Response)
 	case xmlContentType:
-		return xml.NewDecoder(res.Body).Decode(&getInfo.Response)
+		return xml.Unmarshal(res.Body, &getInfo.Response)
 	default:
 		return ErrUnsupportedContentType
 	}
@@ -0,0 +1,55 @@
+package httpclient
+
+import (
+	// Native packages
+	"fmt"
+	"net/http"
+)
+
+// maxErrorBody is the maximum
+// size of the body kept by
+// HTTPError.
+const maxErrorBody = 4 << 10
+
+// HTTPError is returned for the
+// responses whose status is not
+// 2xx. The body of the response
+// is decoded into the field
+// ErrorResponse of the request
+// when it is set.
+type HTTPError struct {
+	Method     string
+	URL        string
+	StatusCode int
+	Header     http.Header
+	// Body is the raw body of the
+	// response, truncated to 4 KB.
+	Body []byte
+}
+
+func (e *HTTPError) Error() string {
+	return fmt.Sprintf("%s %s: unexpected status %d %s", e.Method, e.URL, e.StatusCode, http.StatusText(e.StatusCode))
+}
+
+// newHTTPError returns the error
+// of a non-2xx response, or nil.
+func newHTTPError(req *http.Request, res *Response) error {
+	if res.StatusCode >= 200 && res.StatusCode < 300 {
+		return nil
+	}
+	body := res.Body
+	if len(body) > maxErrorBody {
+		body = body[:maxErrorBody]
+	}
+	u := *req.URL
+	u.User = nil
+	return &HTTPError{
+		Method:     req.Method,
+		URL:        u.String(),
+		StatusCode: res.StatusCode,
+		Header:     res.Header,
+		Body:       body,
+	}
+}
+
+// End-of-file
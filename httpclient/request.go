@@ -0,0 +1,217 @@
+package httpclient
+
+import (
+	// Native packages
+	"bytes"
+	"context"
+	"encoding/json"
+	"encoding/xml"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+type (
+	// Request contains the
+	// information for doing
+	// a request with Do.
+	Request struct {
+		// Method is the HTTP method.
+		// Empty means GET.
+		Method string
+		URL    string
+		// Query is added to the
+		// query string of the URL.
+		Query  url.Values
+		Header http.Header
+		// Username and Password are
+		// sent with the basic
+		// authentication, BearerToken
+		// with the bearer one.
+		Username    string
+		Password    string
+		BearerToken string
+		// IdempotencyKey is sent in the
+		// header of the retry policy and
+		// makes the request retryable.
+		IdempotencyKey string
+		// ContentType is the format of
+		// Body and Response, JSON or
+		// XML. Empty means JSON.
+		ContentType string
+		// Body is encoded in the
+		// ContentType, except []byte
+		// and string which are sent
+		// as they are.
+		Body interface{}
+		// Response receives the decoded
+		// body of a 2xx response.
+		Response interface{}
+		// ErrorResponse receives the
+		// decoded body of a non-2xx
+		// response.
+		ErrorResponse interface{}
+	}
+
+	// Response contains the
+	// status, the headers and the
+	// raw body of a response.
+	Response struct {
+		StatusCode int
+		Header     http.Header
+		Body       []byte
+	}
+)
+
+// Do sends the request with any
+// method (GET, POST, PUT, PATCH,
+// DELETE, HEAD, ...), and returns
+// the response. The body of a 2xx
+// response is decoded into
+// req.Response when it is set.
+// For other statuses, the response
+// is returned with an *HTTPError.
+// The request is canceled when
+// 'ctx' is done.
+func Do(ctx context.Context, req *Request) (*Response, error) {
+	ctx = ctxOf(ctx)
+	method := req.Method
+	if method == "" {
+		method = http.MethodGet
+	}
+	ct := req.ContentType
+	if ct == "" {
+		ct = jsonContentType
+	}
+	target, err := url.Parse(req.URL)
+	if err != nil {
+		return nil, err
+	}
+	if len(req.Query) > 0 {
+		query := target.Query()
+		for key, values := range req.Query {
+			for _, value := range values {
+				query.Add(key, value)
+			}
+		}
+		target.RawQuery = query.Encode()
+	}
+	body, err := encode(ct, req.Body)
+	if err != nil {
+		return nil, err
+	}
+
+	res, err := roundTrip(ctx, ct, req.ErrorResponse, func() (*http.Request, error) {
+		var reader io.Reader
+		if body != nil {
+			reader = bytes.NewReader(body)
+		}
+		r, err := http.NewRequest(method, target.String(), reader)
+		if err != nil {
+			return nil, err
+		}
+		for key, values := range req.Header {
+			for _, value := range values {
+				r.Header.Add(key, value)
+			}
+		}
+		if body != nil && r.Header.Get(contentType) == "" {
+			r.Header.Set(contentType, ct)
+		}
+		if user, pass := req.Username, req.Password; user != "" && pass != "" {
+			r.SetBasicAuth(user, pass)
+		}
+		if req.BearerToken != "" {
+			r.Header.Set("Authorization", "Bearer "+req.BearerToken)
+		}
+		if key, header := req.IdempotencyKey, retryPolicy.IdempotencyKeyHeader; key != "" && header != "" {
+			r.Header.Set(header, key)
+		}
+		return r, nil
+	})
+	if err != nil {
+		return res, err
+	}
+	if req.Response != nil && len(res.Body) > 0 {
+		if err := decode(ct, res.Body, req.Response); err != nil {
+			return res, err
+		}
+	}
+	return res, nil
+}
+
+// roundTrip sends the request created
+// by 'newRequest' and reads the whole
+// response. An *HTTPError is returned
+// with the response when its status
+// is not 2xx, and its body is decoded
+// into 'errorResponse' when set.
+func roundTrip(ctx context.Context, ct string, errorResponse interface{}, newRequest func() (*http.Request, error)) (*Response, error) {
+	var sent *http.Request
+	res, err := do(ctx, func() (*http.Request, error) {
+		req, err := newRequest()
+		sent = req
+		return req, err
+	})
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		if err := res.Body.Close(); err != nil {
+			log.TErrorf(ctx, "Error when close response body, error: %v", err)
+		}
+	}()
+
+	b, err := ioutil.ReadAll(res.Body)
+	if err != nil {
+		return nil, err
+	}
+	response := &Response{StatusCode: res.StatusCode, Header: res.Header, Body: b}
+	if err := newHTTPError(sent, response); err != nil {
+		if errorResponse != nil && len(b) > 0 {
+			if errDecode := decode(ct, b, errorResponse); errDecode != nil {
+				log.TWarnf(ctx, "Can not decode the error response, error: %v", errDecode)
+			}
+		}
+		return response, err
+	}
+	return response, nil
+}
+
+// encode encodes 'v' in the
+// Content-Type 'ct'.
+func encode(ct string, v interface{}) ([]byte, error) {
+	switch b := v.(type) {
+	case nil:
+		return nil, nil
+	case []byte:
+		return b, nil
+	case string:
+		return []byte(b), nil
+	}
+	switch {
+	case strings.Contains(ct, "json"):
+		return json.Marshal(v)
+	case strings.Contains(ct, "xml"):
+		return xml.Marshal(v)
+	default:
+		return nil, ErrUnsupportedContentType
+	}
+}
+
+// decode decodes 'data' in the
+// Content-Type 'ct' into 'v'.
+func decode(ct string, data []byte, v interface{}) error {
+	switch {
+	case strings.Contains(ct, "json"):
+		return json.Unmarshal(data, v)
+	case strings.Contains(ct, "xml"):
+		return xml.Unmarshal(data, v)
+	default:
+		return ErrUnsupportedContentType
+	}
+}
+
+// End-of-file
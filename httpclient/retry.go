@@ -0,0 +1,228 @@
+package httpclient
+
+import (
+	// Native packages
+	"context"
+	"io"
+	"io/ioutil"
+	"math/rand"
+	"net/http"
+	"strconv"
+	"time"
+
+	// Third parties
+	"github.com/go-chi/chi/middleware"
+)
+
+// requestIDHeader is the header
+// forwarding the request ID.
+const requestIDHeader = "X-Request-ID"
+
+// RetryPolicy contains the options
+// for retrying the failed requests.
+type RetryPolicy struct {
+	// MaxAttempts is the maximum number
+	// of attempts of a request, the
+	// first one included. One or less
+	// means no retry.
+	MaxAttempts int
+	// MinBackoff is the delay before the
+	// first retry, doubled after every
+	// retry up to MaxBackoff, with a
+	// random jitter.
+	MinBackoff time.Duration
+	MaxBackoff time.Duration
+	// RetryableStatuses contains the
+	// status codes of the responses
+	// which are retried. Their header
+	// Retry-After is honored, unless it
+	// is longer than MaxBackoff, then
+	// the response is returned.
+	RetryableStatuses []int
+	// IdempotencyKeyHeader is the header
+	// which makes a POST or PATCH request
+	// retryable, the server being
+	// expected to process a key only
+	// once. GET, HEAD, PUT, DELETE and
+	// OPTIONS requests are always
+	// retryable.
+	IdempotencyKeyHeader string
+}
+
+// DefaultRetryPolicy is the policy
+// used until SetRetryPolicy is called.
+var DefaultRetryPolicy = RetryPolicy{
+	MaxAttempts:          3,
+	MinBackoff:           100 * time.Millisecond,
+	MaxBackoff:           2 * time.Second,
+	RetryableStatuses:    []int{http.StatusRequestTimeout, http.StatusTooManyRequests, http.StatusBadGateway, http.StatusServiceUnavailable, http.StatusGatewayTimeout},
+	IdempotencyKeyHeader: "Idempotency-Key",
+}
+
+var retryPolicy = DefaultRetryPolicy
+
+// SetRetryPolicy sets the retry
+// policy of all the requests. It
+// should be called at startup,
+// e.g. after NewHTTPClient.
+func SetRetryPolicy(policy RetryPolicy) {
+	retryPolicy = policy
+}
+
+// idempotent reports whether 'req'
+// can be sent more than once safely.
+func (p RetryPolicy) idempotent(req *http.Request) bool {
+	switch req.Method {
+	case http.MethodGet, http.MethodHead, http.MethodPut, http.MethodDelete, http.MethodOptions, http.MethodTrace:
+		return true
+	}
+	return p.IdempotencyKeyHeader != "" && req.Header.Get(p.IdempotencyKeyHeader) != ""
+}
+
+func (p RetryPolicy) retryableStatus(code int) bool {
+	for _, status := range p.RetryableStatuses {
+		if status == code {
+			return true
+		}
+	}
+	return false
+}
+
+// backoff returns the delay
+// before the retry 'n' (from 1).
+func (p RetryPolicy) backoff(n int) time.Duration {
+	d := p.MinBackoff
+	for i := 1; i < n && (p.MaxBackoff <= 0 || d < p.MaxBackoff); i++ {
+		d *= 2
+	}
+	if p.MaxBackoff > 0 && d > p.MaxBackoff {
+		d = p.MaxBackoff
+	}
+	if d <= 0 {
+		return 0
+	}
+	// Between half and all of the
+	// delay, so that the clients do
+	// not retry at the same time.
+	return d/2 + time.Duration(rand.Int63n(int64(d/2)+1))
+}
+
+// retryAfter parses the header
+// Retry-After of 'res', in seconds
+// or as an HTTP date.
+func retryAfter(res *http.Response) (time.Duration, bool) {
+	value := res.Header.Get("Retry-After")
+	if value == "" {
+		return 0, false
+	}
+	if seconds, err := strconv.Atoi(value); err == nil {
+		return time.Duration(seconds) * time.Second, true
+	}
+	if date, err := http.ParseTime(value); err == nil {
+		return time.Until(date), true
+	}
+	return 0, false
+}
+
+// do sends the request created by
+// 'newRequest' with 'client' and
+// 'ctx', and retries it according
+// to the retry policy. A new
+// request is created for every
+// attempt, so that its body can
+// be read again. The
+// attempts go through the circuit
+// breaker of the host, and the open
+// circuits are not retried.
+func do(ctx context.Context, newRequest func() (*http.Request, error)) (*http.Response, error) {
+	policy := retryPolicy
+	for attempt := 1; ; attempt++ {
+		req, err := newRequest()
+		if err != nil {
+			return nil, err
+		}
+		req = withContext(ctx, req)
+		b := breakerOf(req.URL.Host)
+		var generation uint64
+		if b != nil {
+			if generation, err = b.allow(); err != nil {
+				return nil, err
+			}
+		}
+		res, err := client.Do(req)
+		switch {
+		case b == nil:
+		case err != nil && ctx.Err() != nil:
+			b.release(generation)
+		default:
+			b.record(generation, err == nil && res.StatusCode < http.StatusInternalServerError)
+		}
+
+		retry := attempt < policy.MaxAttempts && policy.idempotent(req)
+		delay := policy.backoff(attempt)
+		switch {
+		case err != nil:
+			if !retry || ctx.Err() != nil {
+				return nil, err
+			}
+			log.TWarnf(ctx, "Retry %s %s in %v, error: %v", req.Method, req.URL.Host+req.URL.Path, delay, err)
+		case policy.retryableStatus(res.StatusCode):
+			if after, ok := retryAfter(res); ok {
+				if policy.MaxBackoff > 0 && after > policy.MaxBackoff {
+					return res, nil
+				}
+				if after > delay {
+					delay = after
+				}
+			}
+			if !retry {
+				return res, nil
+			}
+			log.TWarnf(ctx, "Retry %s %s in %v, status: %d", req.Method, req.URL.Host+req.URL.Path, delay, res.StatusCode)
+			drain(ctx, res)
+		default:
+			return res, nil
+		}
+
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-time.After(delay):
+		}
+	}
+}
+
+// drain reads and closes the body
+// of a discarded response, so that
+// its connection can be reused.
+func drain(ctx context.Context, res *http.Response) {
+	_, _ = io.Copy(ioutil.Discard, io.LimitReader(res.Body, 1<<16))
+	if err := res.Body.Close(); err != nil {
+		log.TErrorf(ctx, "Error when close response body, error: %v", err)
+	}
+}
+
+// withContext binds 'req' to 'ctx',
+// so it is canceled when 'ctx' is
+// done, and forwards the request ID
+// of chi carried by 'ctx' for
+// correlating the logs across the
+// services.
+func withContext(ctx context.Context, req *http.Request) *http.Request {
+	if id := middleware.GetReqID(ctx); id != "" && req.Header.Get(requestIDHeader) == "" {
+		req.Header.Set(requestIDHeader, id)
+	}
+	return req.WithContext(ctx)
+}
+
+// ctxOf returns 'ctx', or the
+// background context when it
+// is nil.
+func ctxOf(ctx context.Context) context.Context {
+	if ctx == nil {
+		return context.Background()
+	}
+	return ctx
+}
+
+// End-of-file
@@ -0,0 +1,100 @@
+package mongo
+
+import (
+	// Native packages
+	"context"
+	"reflect"
+	"time"
+
+	// Third parties
+	"github.com/globalsign/mgo"
+)
+
+// AggregateOptions contains the
+// options for running an aggregation
+// pipeline.
+type AggregateOptions struct {
+	// AllowDiskUse lets the stages of
+	// the pipeline write temporary data
+	// on disk when exceeding the memory
+	// limit of the server.
+	AllowDiskUse bool
+	// MaxTime limits the execution time
+	// of the pipeline on the server.
+	// Zero means no limit, other than
+	// the deadline of the context.
+	MaxTime time.Duration
+	// BatchSize is the number of records
+	// fetched per batch. Zero means the
+	// default of the server.
+	BatchSize int
+}
+
+// pipe builds the aggregation pipe
+// on session 's' based on 'opts',
+// limited by the deadline of 'ctx'.
+func pipe(ctx context.Context, s *mgo.Session, database, collection string, pipeline interface{}, opts AggregateOptions) *mgo.Pipe {
+	p := s.DB(database).C(collection).Pipe(pipeline)
+	if opts.AllowDiskUse {
+		p = p.AllowDiskUse()
+	}
+	maxTime := opts.MaxTime
+	if timeout, ok := timeoutOf(ctx); ok && timeout > 0 && (maxTime == 0 || timeout < maxTime) {
+		maxTime = timeout
+	}
+	if maxTime > 0 {
+		p = p.SetMaxTime(maxTime)
+	}
+	if opts.BatchSize > 0 {
+		p = p.Batch(opts.BatchSize)
+	}
+	return p
+}
+
+// Aggregate runs the aggregation
+// 'pipeline' on 'collection' and
+// puts all the results into 'result',
+// which must be a slice address.
+func (c *Client) Aggregate(database, collection string, pipeline, result interface{}) error {
+	return c.AggregateCtx(context.Background(), database, collection, pipeline, result, AggregateOptions{})
+}
+
+// AggregateWithOptions is the same
+// as Aggregate but runs the pipeline
+// based on 'opts'.
+func (c *Client) AggregateWithOptions(database, collection string, pipeline, result interface{}, opts AggregateOptions) error {
+	return c.AggregateCtx(context.Background(), database, collection, pipeline, result, opts)
+}
+
+// AggregateCtx is the same as
+// AggregateWithOptions but bounded
+// by 'ctx'.
+func (c *Client) AggregateCtx(ctx context.Context, database, collection string, pipeline, result interface{}, opts AggregateOptions) error {
+	if reflect.TypeOf(result).Kind() != reflect.Ptr ||
+		reflect.TypeOf(result).Elem().Kind() != reflect.Slice {
+		return ErrNotSliceAddress
+	}
+	return c.runInto(ctx, result, func(s *mgo.Session, result interface{}) error {
+		return pipe(ctx, s, database, collection, pipeline, opts).All(result)
+	})
+}
+
+// AggregateIter runs the aggregation
+// 'pipeline' on 'collection' and
+// returns an iterator over the results
+// for streaming large outputs.
+// Do not forget to close the
+// iterator after using it.
+func (c *Client) AggregateIter(database, collection string, pipeline interface{}, opts AggregateOptions) *Iterator {
+	return c.AggregateIterCtx(context.Background(), database, collection, pipeline, opts)
+}
+
+// AggregateIterCtx is the same as
+// AggregateIter but bounded by 'ctx'.
+func (c *Client) AggregateIterCtx(ctx context.Context, database, collection string, pipeline interface{}, opts AggregateOptions) *Iterator {
+	return c.newIterator(ctx, func(s *mgo.Session) *mgo.Iter {
+		return pipe(ctx, s, database, collection, pipeline, opts).Iter()
+	})
+}
+
+// End-of-file
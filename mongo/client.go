@@ -0,0 +1,143 @@
+package mongo
+
+import (
+	// Native packages
+	"context"
+	"strings"
+
+	// Third parties
+	"github.com/globalsign/mgo"
+)
+
+// Client holds the session of
+// connection to one MongoDB
+// cluster. Several clients can
+// be used at the same time for
+// talking to different clusters.
+type Client struct {
+	session *mgo.Session
+}
+
+// New creates a client connected
+// to MongoDB based on the given
+// configuration. The parameter
+// 'collections' is for indexing
+// to make sure the correctness
+// and fasten of retrieving data.
+func New(cfg Configs, collections []Collection) (*Client, error) {
+	dialInfo := mgo.DialInfo{
+		Addrs:    strings.Split(cfg.Addresses, ","),
+		Database: cfg.Database,
+		Username: cfg.Username,
+		Password: cfg.Password,
+		Timeout:  cfg.Timeout,
+	}
+
+	switch s, err := mgo.DialWithInfo(&dialInfo); {
+	case err != nil:
+		return nil, err
+	default:
+		s.SetMode(mgo.Monotonic, true)
+		c := &Client{session: s}
+		if err := c.EnsureIndices(collections); err != nil {
+			s.Close()
+			return nil, err
+		}
+		return c, nil
+	}
+}
+
+// Close closes the session
+// of the client.
+func (c *Client) Close() {
+	if c != nil && c.session != nil {
+		c.session.Close()
+		c.session = nil
+	}
+}
+
+func (c *Client) cloneSession() *mgo.Session {
+	if c == nil || c.session == nil {
+		return nil
+	}
+	return c.session.Copy()
+}
+
+// EnsureIndices ensures the indices
+// in the parameter 'collections'
+// to make sure the correctness and
+// fasten the retrieving data.
+func (c *Client) EnsureIndices(collections []Collection) error {
+	return c.EnsureIndicesCtx(context.Background(), collections)
+}
+
+// Find queries from 'collection'
+// the first record that satisfied
+// the 'selector' into 'result'.
+func (c *Client) Find(database, collection string, selector, result interface{}) error {
+	return c.FindCtx(context.Background(), database, collection, selector, result)
+}
+
+// FindAll queries from 'collection'
+// all the records that satisfied
+// the 'selector' into 'result',
+// which must be a slice address.
+func (c *Client) FindAll(database, collection string, selector, result interface{}) error {
+	return c.FindAllCtx(context.Background(), database, collection, selector, result)
+}
+
+// Insert creates a record in
+// 'collection' with the value
+// of 'data'.
+func (c *Client) Insert(database, collection string, data interface{}) error {
+	return c.InsertCtx(context.Background(), database, collection, data)
+}
+
+// InsertAll creates records in
+// 'collection' with the values
+// from 'list', which can only be
+// a slice or pointer of a slice.
+func (c *Client) InsertAll(database, collection string, list interface{}) error {
+	return c.InsertAllCtx(context.Background(), database, collection, list)
+}
+
+// Remove deletes the first record
+// that satisfied 'selector' from
+// 'collection'.
+func (c *Client) Remove(database, collection string, selector interface{}) error {
+	return c.RemoveCtx(context.Background(), database, collection, selector)
+}
+
+// RemoveAll deletes all the records
+// that satisfied 'selector' from
+// 'collection'.
+func (c *Client) RemoveAll(database, collection string, selector interface{}) error {
+	return c.RemoveAllCtx(context.Background(), database, collection, selector)
+}
+
+// Update updates the first record
+// in 'collection' that satisfied
+// 'selector', with the new data
+// 'updater'.
+func (c *Client) Update(database, collection string, selector, updater interface{}) error {
+	return c.UpdateCtx(context.Background(), database, collection, selector, updater)
+}
+
+// UpdateAll updates all the records
+// in 'collection' that satisfied
+// 'selector', with the new data
+// 'updater'.
+func (c *Client) UpdateAll(database, collection string, selector, updater interface{}) error {
+	return c.UpdateAllCtx(context.Background(), database, collection, selector, updater)
+}
+
+// Change updates the first record
+// in 'collection' that satisfied
+// 'selector' with new data 'new',
+// and puts the latest version of
+// the record into 'result'.
+func (c *Client) Change(database, collection string, selector, new, result interface{}) error {
+	return c.ChangeCtx(context.Background(), database, collection, selector, new, result)
+}
+
+// End-of-file
@@ -0,0 +1,219 @@
+package mongo
+
+import (
+	// Native packages
+	"context"
+	"reflect"
+	"time"
+
+	// Third parties
+	"github.com/globalsign/mgo"
+)
+
+// run executes 'fn' on a copy of
+// the client session bounded by
+// 'ctx'. The deadline of 'ctx' is
+// enforced as the socket timeout
+// of the session, and run returns
+// ctx.Err() as soon as 'ctx' is
+// done, while 'fn' may still be
+// running. The results must be
+// decoded with runInto.
+func (c *Client) run(ctx context.Context, fn func(s *mgo.Session) error) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	s := c.cloneSession()
+	if s == nil {
+		return ErrInitialized
+	}
+	if timeout, ok := timeoutOf(ctx); ok {
+		if timeout <= 0 {
+			s.Close()
+			return context.DeadlineExceeded
+		}
+		s.SetSocketTimeout(timeout)
+		s.SetSyncTimeout(timeout)
+	}
+
+	// Context which can never be
+	// canceled, no need to watch.
+	if ctx.Done() == nil {
+		defer s.Close()
+		return fn(s)
+	}
+
+	done := make(chan error, 1)
+	go func() {
+		defer s.Close()
+		done <- fn(s)
+	}()
+	select {
+	case err := <-done:
+		return err
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// runInto is the same as run, but
+// 'fn' decodes into a private value
+// of the type pointed by 'result',
+// which is copied into 'result' only
+// when 'fn' succeeds. So 'result' is
+// never written after run returned
+// on 'ctx' done.
+func (c *Client) runInto(ctx context.Context, result interface{}, fn func(s *mgo.Session, result interface{}) error) error {
+	v := reflect.ValueOf(result)
+	if v.Kind() != reflect.Ptr || v.IsNil() {
+		return c.run(ctx, func(s *mgo.Session) error {
+			return fn(s, result)
+		})
+	}
+	private := reflect.New(v.Type().Elem())
+	err := c.run(ctx, func(s *mgo.Session) error {
+		return fn(s, private.Interface())
+	})
+	if err != nil {
+		return err
+	}
+	v.Elem().Set(private.Elem())
+	return nil
+}
+
+// timeoutOf returns the remaining
+// time before the deadline of 'ctx'
+// and whether 'ctx' has a deadline.
+func timeoutOf(ctx context.Context) (time.Duration, bool) {
+	deadline, ok := ctx.Deadline()
+	if !ok {
+		return 0, false
+	}
+	return time.Until(deadline), true
+}
+
+// withMaxTime limits the execution
+// time of query 'q' on the server
+// side to the deadline of 'ctx'.
+func withMaxTime(ctx context.Context, q *mgo.Query) *mgo.Query {
+	if timeout, ok := timeoutOf(ctx); ok && timeout > 0 {
+		q.SetMaxTime(timeout)
+	}
+	return q
+}
+
+// EnsureIndicesCtx is the same as
+// EnsureIndices but bounded by 'ctx'.
+func (c *Client) EnsureIndicesCtx(ctx context.Context, collections []Collection) error {
+	if collections == nil {
+		return nil
+	}
+	return c.run(ctx, func(s *mgo.Session) error {
+		for _, collection := range collections {
+			for _, index := range collection.Indices {
+				if err := s.DB("").C(collection.Name).EnsureIndex(index); err != nil {
+					_ = s.DB("").C(collection.Name).DropIndexName(index.Name)
+					if err := s.DB("").C(collection.Name).EnsureIndex(index); err != nil {
+						return err
+					}
+				}
+			}
+		}
+		return nil
+	})
+}
+
+// FindCtx is the same as Find
+// but bounded by 'ctx'.
+func (c *Client) FindCtx(ctx context.Context, database, collection string, selector, result interface{}) error {
+	return c.runInto(ctx, result, func(s *mgo.Session, result interface{}) error {
+		return withMaxTime(ctx, s.DB(database).C(collection).Find(selector)).One(result)
+	})
+}
+
+// FindAllCtx is the same as FindAll
+// but bounded by 'ctx'.
+func (c *Client) FindAllCtx(ctx context.Context, database, collection string, selector, result interface{}) error {
+	if reflect.TypeOf(result).Kind() != reflect.Ptr ||
+		reflect.TypeOf(result).Elem().Kind() != reflect.Slice {
+		return ErrNotSliceAddress
+	}
+	return c.runInto(ctx, result, func(s *mgo.Session, result interface{}) error {
+		return withMaxTime(ctx, s.DB(database).C(collection).Find(selector)).All(result)
+	})
+}
+
+// InsertCtx is the same as Insert
+// but bounded by 'ctx'.
+func (c *Client) InsertCtx(ctx context.Context, database, collection string, data interface{}) error {
+	return c.run(ctx, func(s *mgo.Session) error {
+		return s.DB(database).C(collection).Insert(data)
+	})
+}
+
+// InsertAllCtx is the same as
+// InsertAll but bounded by 'ctx'.
+func (c *Client) InsertAllCtx(ctx context.Context, database, collection string, list interface{}) error {
+	slice := reflect.Indirect(reflect.ValueOf(list))
+	if slice.Kind() != reflect.Slice {
+		return ErrSliceOrPointerOfSliceOnly
+	}
+	ret := make([]interface{}, slice.Len())
+	for i := 0; i < slice.Len(); i++ {
+		ret[i] = slice.Index(i).Interface()
+	}
+	return c.run(ctx, func(s *mgo.Session) error {
+		bulk := s.DB(database).C(collection).Bulk()
+		for _, item := range ret {
+			bulk.Insert(item)
+		}
+		_, err := bulk.Run()
+		return err
+	})
+}
+
+// RemoveCtx is the same as Remove
+// but bounded by 'ctx'.
+func (c *Client) RemoveCtx(ctx context.Context, database, collection string, selector interface{}) error {
+	return c.run(ctx, func(s *mgo.Session) error {
+		return s.DB(database).C(collection).Remove(selector)
+	})
+}
+
+// RemoveAllCtx is the same as
+// RemoveAll but bounded by 'ctx'.
+func (c *Client) RemoveAllCtx(ctx context.Context, database, collection string, selector interface{}) error {
+	return c.run(ctx, func(s *mgo.Session) error {
+		_, err := s.DB(database).C(collection).RemoveAll(selector)
+		return err
+	})
+}
+
+// UpdateCtx is the same as Update
+// but bounded by 'ctx'.
+func (c *Client) UpdateCtx(ctx context.Context, database, collection string, selector, updater interface{}) error {
+	return c.run(ctx, func(s *mgo.Session) error {
+		return s.DB(database).C(collection).Update(selector, updater)
+	})
+}
+
+// UpdateAllCtx is the same as
+// UpdateAll but bounded by 'ctx'.
+func (c *Client) UpdateAllCtx(ctx context.Context, database, collection string, selector, updater interface{}) error {
+	return c.run(ctx, func(s *mgo.Session) error {
+		_, err := s.DB(database).C(collection).UpdateAll(selector, updater)
+		return err
+	})
+}
+
+// ChangeCtx is the same as Change
+// but bounded by 'ctx'.
+func (c *Client) ChangeCtx(ctx context.Context, database, collection string, selector, new, result interface{}) error {
+	return c.runInto(ctx, result, func(s *mgo.Session, result interface{}) error {
+		change := mgo.Change{Update: new, ReturnNew: true}
+		_, err := withMaxTime(ctx, s.DB(database).C(collection).Find(selector)).Apply(change, result)
+		return err
+	})
+}
+
+// End-of-file
@@ -0,0 +1,152 @@
+package mongo
+
+import (
+	// Native packages
+	"context"
+
+	// Third parties
+	"github.com/globalsign/mgo"
+	"github.com/globalsign/mgo/bson"
+)
+
+// Iterator walks through the records
+// of a query one at a time, without
+// holding all of them in memory.
+// The iterator must be closed after
+// using it to release its session.
+type Iterator struct {
+	ctx     context.Context
+	session *mgo.Session
+	iter    *mgo.Iter
+	err     error
+}
+
+// newIterator opens a copy of the
+// client session bounded by 'ctx'
+// and builds the iterator with the
+// underlying iterator from 'open'.
+func (c *Client) newIterator(ctx context.Context, open func(s *mgo.Session) *mgo.Iter) *Iterator {
+	it := &Iterator{ctx: ctx}
+	if it.err = ctx.Err(); it.err != nil {
+		return it
+	}
+	s := c.cloneSession()
+	if s == nil {
+		it.err = ErrInitialized
+		return it
+	}
+	if timeout, ok := timeoutOf(ctx); ok {
+		if timeout <= 0 {
+			s.Close()
+			it.err = context.DeadlineExceeded
+			return it
+		}
+		s.SetSocketTimeout(timeout)
+		s.SetSyncTimeout(timeout)
+	}
+	it.session = s
+	it.iter = open(s)
+	return it
+}
+
+// Next decodes the next record into
+// 'result' and reports whether there
+// was one. Next returns false when
+// the records are exhausted or an
+// error occurred, see Err.
+func (it *Iterator) Next(result interface{}) bool {
+	if it.err != nil || it.iter == nil {
+		return false
+	}
+	if err := it.ctx.Err(); err != nil {
+		it.err = err
+		return false
+	}
+	return it.iter.Next(result)
+}
+
+// Err returns the error occurred
+// while iterating, if any.
+func (it *Iterator) Err() error {
+	if it.err != nil {
+		return it.err
+	}
+	if it.iter != nil {
+		return it.iter.Err()
+	}
+	return nil
+}
+
+// Close closes the iterator and
+// releases its session. It returns
+// the error occurred while iterating,
+// if any.
+func (it *Iterator) Close() error {
+	var err error
+	if it.iter != nil {
+		err = it.iter.Close()
+		it.iter = nil
+	}
+	if it.session != nil {
+		it.session.Close()
+		it.session = nil
+	}
+	if it.err != nil {
+		return it.err
+	}
+	return err
+}
+
+// Iter returns an iterator over the
+// records in 'collection' that
+// satisfied the 'selector'. The
+// records are fetched from MongoDB
+// by batches of 'batchSize' (zero
+// means the default of the server).
+func (c *Client) Iter(database, collection string, selector interface{}, batchSize int) *Iterator {
+	return c.IterCtx(context.Background(), database, collection, selector, batchSize)
+}
+
+// IterCtx is the same as Iter
+// but bounded by 'ctx'.
+func (c *Client) IterCtx(ctx context.Context, database, collection string, selector interface{}, batchSize int) *Iterator {
+	return c.newIterator(ctx, func(s *mgo.Session) *mgo.Iter {
+		q := s.DB(database).C(collection).Find(selector)
+		if batchSize > 0 {
+			q = q.Batch(batchSize)
+		}
+		return q.Iter()
+	})
+}
+
+// ForEach calls 'fn' with each record
+// in 'collection' that satisfied the
+// 'selector', one at a time. The raw
+// record can be decoded with its
+// Unmarshal method. ForEach stops
+// and returns the error as soon as
+// 'fn' returns one.
+func (c *Client) ForEach(database, collection string, selector interface{}, fn func(doc bson.Raw) error) error {
+	return c.ForEachCtx(context.Background(), database, collection, selector, fn)
+}
+
+// ForEachCtx is the same as ForEach
+// but bounded by 'ctx'.
+func (c *Client) ForEachCtx(ctx context.Context, database, collection string, selector interface{}, fn func(doc bson.Raw) error) error {
+	return forEach(c.IterCtx(ctx, database, collection, selector, 0), fn)
+}
+
+// forEach calls 'fn' with each
+// record of 'it' then closes it.
+func forEach(it *Iterator, fn func(doc bson.Raw) error) error {
+	var doc bson.Raw
+	for it.Next(&doc) {
+		if err := fn(doc); err != nil {
+			_ = it.Close()
+			return err
+		}
+	}
+	return it.Close()
+}
+
+// End-of-file
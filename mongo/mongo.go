@@ -2,13 +2,13 @@ package mongo
 
 import (
 	// Native packages
+	"context"
 	"errors"
-	"reflect"
-	"strings"
 	"time"
 
 	// Third parties
 	"github.com/globalsign/mgo"
+	"github.com/globalsign/mgo/bson"
 )
 
 // Configs contains the configuration
@@ -30,11 +30,11 @@ type Collection struct {
 }
 
 var (
-	// session holds the session
-	// of connection to MongoDB
-	// and can be used only inside
-	// this package.
-	session *mgo.Session
+	// defaultClient holds the client
+	// created by NewMongoClient and
+	// is used by the package-level
+	// helpers.
+	defaultClient *Client
 
 	// ErrInitialized is returned when
 	// cannot clone from the current
@@ -66,6 +66,16 @@ var (
 	// when the argument is not pointer
 	// of a struct.
 	ErrNotPointerOfStruct = errors.New("argument must be a pointer of struct")
+
+	// ErrInvalidCursor is returned
+	// when the cursor token of a page
+	// cannot be decoded.
+	ErrInvalidCursor = errors.New("invalid cursor")
+
+	// ErrCursorNotSortedByID is returned
+	// when a cursor is given while the
+	// page is not sorted by _id.
+	ErrCursorNotSortedByID = errors.New("cursor can only be used when sorting by _id")
 )
 
 // NewMongoClient creates an instance
@@ -79,64 +89,33 @@ var (
 // for indexing to make sure the
 // correctness and fasten of
 // retrieving data.
+// The created client becomes the
+// default client used by the
+// package-level helpers.
 func NewMongoClient(cfg Configs, collections []Collection) error {
-	dialInfo := mgo.DialInfo{
-		Addrs:    strings.Split(cfg.Addresses, ","),
-		Database: cfg.Database,
-		Username: cfg.Username,
-		Password: cfg.Password,
-		Timeout:  cfg.Timeout,
-	}
-
-	switch s, err := mgo.DialWithInfo(&dialInfo); {
-	case err != nil:
+	c, err := New(cfg, collections)
+	if err != nil {
 		return err
-	default:
-		s.SetMode(mgo.Monotonic, true)
-		session = s
-		if err := EnsureIndices(collections); err != nil {
-			session = nil
-			return err
-		}
-		return nil
 	}
+	defaultClient = c
+	return nil
 }
 
 // Close closes the session
 // to connect with MongoDB.
 func Close() {
-	if session != nil {
-		session.Close()
-		session = nil
-		return
+	if defaultClient != nil {
+		defaultClient.Close()
+		defaultClient = nil
 	}
 }
 
-func cloneSession() *mgo.Session {
-	if session == nil {
-		return nil
-	}
-	return session.Copy()
-}
-
 // EnsureIndices ensures the indices
 // in the parameter 'collections'
 // to make sure the correctness and
 // fasten the retrieving data.
 func EnsureIndices(collections []Collection) error {
-	if collections != nil {
-		for _, collection := range collections {
-			for _, index := range collection.Indices {
-				if err := session.DB("").C(collection.Name).EnsureIndex(index); err != nil {
-					_ = session.DB("").C(collection.Name).DropIndexName(index.Name)
-					if err := session.DB("").C(collection.Name).EnsureIndex(index); err != nil {
-						return err
-					}
-				}
-			}
-		}
-	}
-	return nil
+	return defaultClient.EnsureIndices(collections)
 }
 
 // Find queries from 'collection'
@@ -148,12 +127,7 @@ func EnsureIndices(collections []Collection) error {
 // it will consider using database
 // when initiate connection.
 func Find(database, collection string, selector, result interface{}) error {
-	s := cloneSession()
-	if s == nil {
-		return ErrInitialized
-	}
-	defer s.Close()
-	return s.DB(database).C(collection).Find(selector).One(result)
+	return defaultClient.Find(database, collection, selector, result)
 }
 
 // FindAll queries from 'collection'
@@ -168,16 +142,7 @@ func Find(database, collection string, selector, result interface{}) error {
 // it will consider using database
 // when initiate connection.
 func FindAll(database, collection string, selector, result interface{}) error {
-	if reflect.TypeOf(result).Kind() != reflect.Ptr ||
-		reflect.TypeOf(result).Elem().Kind() != reflect.Slice {
-		return ErrNotSliceAddress
-	}
-	s := cloneSession()
-	if s == nil {
-		return ErrInitialized
-	}
-	defer s.Close()
-	return s.DB(database).C(collection).Find(selector).All(result)
+	return defaultClient.FindAll(database, collection, selector, result)
 }
 
 // Insert creates a record in
@@ -188,12 +153,7 @@ func FindAll(database, collection string, selector, result interface{}) error {
 // it will consider using database
 // when initiate connection.
 func Insert(database, collection string, data interface{}) error {
-	s := cloneSession()
-	if s == nil {
-		return ErrInitialized
-	}
-	defer s.Close()
-	return s.DB(database).C(collection).Insert(data)
+	return defaultClient.Insert(database, collection, data)
 }
 
 // InsertAll creates records in
@@ -206,29 +166,7 @@ func Insert(database, collection string, data interface{}) error {
 // it will consider using database
 // when initiate connection.
 func InsertAll(database, collection string, list interface{}) error {
-	slice := reflect.ValueOf(list)
-	if slice.Kind() != reflect.Slice {
-		if reflect.TypeOf(list).Kind() != reflect.Ptr ||
-			reflect.TypeOf(list).Kind() == reflect.Ptr &&
-				reflect.TypeOf(list).Elem().Kind() != reflect.Slice {
-			return ErrSliceOrPointerOfSliceOnly
-		}
-	}
-	ret := make([]interface{}, slice.Len())
-	for i := 0; i < slice.Len(); i++ {
-		ret[i] = slice.Index(i).Interface()
-	}
-	s := cloneSession()
-	if s == nil {
-		return ErrInitialized
-	}
-	defer s.Close()
-	bulk := s.DB(database).C(collection).Bulk()
-	for _, item := range ret {
-		bulk.Insert(item)
-	}
-	_, err := bulk.Run()
-	return err
+	return defaultClient.InsertAll(database, collection, list)
 }
 
 // Remove deletes the first record
@@ -239,12 +177,7 @@ func InsertAll(database, collection string, list interface{}) error {
 // it will consider using database
 // when initiate connection.
 func Remove(database, collection string, selector interface{}) error {
-	s := cloneSession()
-	if s == nil {
-		return ErrInitialized
-	}
-	defer s.Close()
-	return s.DB(database).C(collection).Remove(selector)
+	return defaultClient.Remove(database, collection, selector)
 }
 
 // RemoveAll deletes all the records
@@ -255,13 +188,7 @@ func Remove(database, collection string, selector interface{}) error {
 // it will consider using database
 // when initiate connection.
 func RemoveAll(database, collection string, selector interface{}) error {
-	s := cloneSession()
-	if s == nil {
-		return ErrInitialized
-	}
-	defer s.Close()
-	_, err := s.DB(database).C(collection).RemoveAll(selector)
-	return err
+	return defaultClient.RemoveAll(database, collection, selector)
 }
 
 // Update updates the first record
@@ -273,12 +200,7 @@ func RemoveAll(database, collection string, selector interface{}) error {
 // it will consider using database
 // when initiate connection.
 func Update(database, collection string, selector, updater interface{}) error {
-	s := cloneSession()
-	if s == nil {
-		return ErrInitialized
-	}
-	defer s.Close()
-	return s.DB(database).C(collection).Update(selector, updater)
+	return defaultClient.Update(database, collection, selector, updater)
 }
 
 // UpdateAll updates all the records
@@ -290,13 +212,7 @@ func Update(database, collection string, selector, updater interface{}) error {
 // it will consider using database
 // when initiate connection.
 func UpdateAll(database, collection string, selector, updater interface{}) error {
-	s := cloneSession()
-	if s == nil {
-		return ErrInitialized
-	}
-	defer s.Close()
-	_, err := s.DB(database).C(collection).UpdateAll(selector, updater)
-	return err
+	return defaultClient.UpdateAll(database, collection, selector, updater)
 }
 
 // Change updates the first record
@@ -311,14 +227,176 @@ func UpdateAll(database, collection string, selector, updater interface{}) error
 // it will consider using database
 // when initiate connection.
 func Change(database, collection string, selector, new, result interface{}) error {
-	s := cloneSession()
-	if s == nil {
-		return ErrInitialized
-	}
-	defer s.Close()
-	change := mgo.Change{Update: new, ReturnNew: true}
-	_, err := s.DB(database).C(collection).Find(selector).Apply(change, result)
-	return err
+	return defaultClient.Change(database, collection, selector, new, result)
+}
+
+// EnsureIndicesCtx is the same as
+// EnsureIndices but bounded by 'ctx'.
+func EnsureIndicesCtx(ctx context.Context, collections []Collection) error {
+	return defaultClient.EnsureIndicesCtx(ctx, collections)
+}
+
+// FindCtx is the same as Find but
+// takes a context. The deadline of
+// 'ctx' is enforced as the socket
+// and query timeout, and FindCtx
+// returns ctx.Err() promptly when
+// 'ctx' is canceled. 'result' is
+// written only when the query
+// succeeds, which holds for all
+// the ...Ctx variants.
+func FindCtx(ctx context.Context, database, collection string, selector, result interface{}) error {
+	return defaultClient.FindCtx(ctx, database, collection, selector, result)
+}
+
+// FindAllCtx is the same as FindAll
+// but bounded by 'ctx'.
+func FindAllCtx(ctx context.Context, database, collection string, selector, result interface{}) error {
+	return defaultClient.FindAllCtx(ctx, database, collection, selector, result)
+}
+
+// InsertCtx is the same as Insert
+// but bounded by 'ctx'.
+func InsertCtx(ctx context.Context, database, collection string, data interface{}) error {
+	return defaultClient.InsertCtx(ctx, database, collection, data)
+}
+
+// InsertAllCtx is the same as
+// InsertAll but bounded by 'ctx'.
+func InsertAllCtx(ctx context.Context, database, collection string, list interface{}) error {
+	return defaultClient.InsertAllCtx(ctx, database, collection, list)
+}
+
+// RemoveCtx is the same as Remove
+// but bounded by 'ctx'.
+func RemoveCtx(ctx context.Context, database, collection string, selector interface{}) error {
+	return defaultClient.RemoveCtx(ctx, database, collection, selector)
+}
+
+// RemoveAllCtx is the same as
+// RemoveAll but bounded by 'ctx'.
+func RemoveAllCtx(ctx context.Context, database, collection string, selector interface{}) error {
+	return defaultClient.RemoveAllCtx(ctx, database, collection, selector)
+}
+
+// UpdateCtx is the same as Update
+// but bounded by 'ctx'.
+func UpdateCtx(ctx context.Context, database, collection string, selector, updater interface{}) error {
+	return defaultClient.UpdateCtx(ctx, database, collection, selector, updater)
+}
+
+// UpdateAllCtx is the same as
+// UpdateAll but bounded by 'ctx'.
+func UpdateAllCtx(ctx context.Context, database, collection string, selector, updater interface{}) error {
+	return defaultClient.UpdateAllCtx(ctx, database, collection, selector, updater)
+}
+
+// ChangeCtx is the same as Change
+// but bounded by 'ctx'.
+func ChangeCtx(ctx context.Context, database, collection string, selector, new, result interface{}) error {
+	return defaultClient.ChangeCtx(ctx, database, collection, selector, new, result)
+}
+
+// FindPage queries from 'collection'
+// a page of the records that satisfied
+// the 'selector' into 'result', which
+// must be a slice address. The page
+// is shaped by 'opts' (sort, projection,
+// skip, limit and cursor) and holds the
+// total number of records and the
+// cursor of the next page.
+// FindPage accepts empty 'database'.
+// In the case of empty 'database',
+// it will consider using database
+// when initiate connection.
+func FindPage(database, collection string, selector interface{}, opts QueryOptions, result interface{}) (*Page, error) {
+	return defaultClient.FindPage(database, collection, selector, opts, result)
+}
+
+// FindPageCtx is the same as
+// FindPage but bounded by 'ctx'.
+func FindPageCtx(ctx context.Context, database, collection string, selector interface{}, opts QueryOptions, result interface{}) (*Page, error) {
+	return defaultClient.FindPageCtx(ctx, database, collection, selector, opts, result)
+}
+
+// Iter returns an iterator over the
+// records in 'collection' that
+// satisfied the 'selector', fetched
+// by batches of 'batchSize'.
+// Do not forget to close the
+// iterator after using it.
+// Iter accepts empty 'database'.
+// In the case of empty 'database',
+// it will consider using database
+// when initiate connection.
+func Iter(database, collection string, selector interface{}, batchSize int) *Iterator {
+	return defaultClient.Iter(database, collection, selector, batchSize)
+}
+
+// IterCtx is the same as Iter
+// but bounded by 'ctx'.
+func IterCtx(ctx context.Context, database, collection string, selector interface{}, batchSize int) *Iterator {
+	return defaultClient.IterCtx(ctx, database, collection, selector, batchSize)
+}
+
+// ForEach calls 'fn' with each record
+// in 'collection' that satisfied the
+// 'selector', one at a time, and stops
+// as soon as 'fn' returns an error.
+// ForEach accepts empty 'database'.
+// In the case of empty 'database',
+// it will consider using database
+// when initiate connection.
+func ForEach(database, collection string, selector interface{}, fn func(doc bson.Raw) error) error {
+	return defaultClient.ForEach(database, collection, selector, fn)
+}
+
+// ForEachCtx is the same as ForEach
+// but bounded by 'ctx'.
+func ForEachCtx(ctx context.Context, database, collection string, selector interface{}, fn func(doc bson.Raw) error) error {
+	return defaultClient.ForEachCtx(ctx, database, collection, selector, fn)
+}
+
+// Aggregate runs the aggregation
+// 'pipeline' on 'collection' and
+// puts all the results into 'result',
+// which must be a slice address.
+// Aggregate accepts empty 'database'.
+// In the case of empty 'database',
+// it will consider using database
+// when initiate connection.
+func Aggregate(database, collection string, pipeline, result interface{}) error {
+	return defaultClient.Aggregate(database, collection, pipeline, result)
+}
+
+// AggregateWithOptions is the same
+// as Aggregate but runs the pipeline
+// based on 'opts' (allowDiskUse,
+// max time and batch size).
+func AggregateWithOptions(database, collection string, pipeline, result interface{}, opts AggregateOptions) error {
+	return defaultClient.AggregateWithOptions(database, collection, pipeline, result, opts)
+}
+
+// AggregateCtx is the same as
+// AggregateWithOptions but bounded
+// by 'ctx'.
+func AggregateCtx(ctx context.Context, database, collection string, pipeline, result interface{}, opts AggregateOptions) error {
+	return defaultClient.AggregateCtx(ctx, database, collection, pipeline, result, opts)
+}
+
+// AggregateIter runs the aggregation
+// 'pipeline' on 'collection' and
+// returns an iterator over the results.
+// Do not forget to close the
+// iterator after using it.
+func AggregateIter(database, collection string, pipeline interface{}, opts AggregateOptions) *Iterator {
+	return defaultClient.AggregateIter(database, collection, pipeline, opts)
+}
+
+// AggregateIterCtx is the same as
+// AggregateIter but bounded by 'ctx'.
+func AggregateIterCtx(ctx context.Context, database, collection string, pipeline interface{}, opts AggregateOptions) *Iterator {
+	return defaultClient.AggregateIterCtx(ctx, database, collection, pipeline, opts)
 }
 
 // End-of-file
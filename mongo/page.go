@@ -0,0 +1,168 @@
+package mongo
+
+import (
+	// Native packages
+	"context"
+	"encoding/base64"
+	"reflect"
+
+	// Third parties
+	"github.com/globalsign/mgo"
+	"github.com/globalsign/mgo/bson"
+)
+
+// QueryOptions contains the options
+// for querying a page of records.
+type QueryOptions struct {
+	// Sort contains the fields to sort
+	// by, prefixed with '-' for the
+	// descending order (e.g. "-age").
+	// Empty Sort means sorting by _id.
+	Sort []string
+	// Projection selects the fields
+	// to return (e.g. bson.M{"name": 1}).
+	Projection interface{}
+	// Skip is the number of records
+	// to skip before the page.
+	Skip int
+	// Limit is the maximum number of
+	// records of the page. Zero means
+	// no limit.
+	Limit int
+	// After is the cursor token taken
+	// from Page.NextCursor. Only the
+	// records with _id greater than
+	// the cursor are returned. It
+	// can only be used when sorting
+	// by _id.
+	After string
+}
+
+// Page contains a page of records
+// queried by FindPage and can be
+// rendered directly as JSON.
+type Page struct {
+	Items      interface{} `json:"items"`
+	Total      int         `json:"total"`
+	NextCursor string      `json:"next_cursor,omitempty"`
+}
+
+// FindPage queries from 'collection'
+// a page of the records that satisfied
+// the 'selector' into 'result', which
+// must be a slice address. The total
+// number of records that satisfied
+// the 'selector' and the cursor of
+// the next page are returned along
+// with the records.
+func (c *Client) FindPage(database, collection string, selector interface{}, opts QueryOptions, result interface{}) (*Page, error) {
+	return c.FindPageCtx(context.Background(), database, collection, selector, opts, result)
+}
+
+// FindPageCtx is the same as
+// FindPage but bounded by 'ctx'.
+func (c *Client) FindPageCtx(ctx context.Context, database, collection string, selector interface{}, opts QueryOptions, result interface{}) (*Page, error) {
+	if reflect.TypeOf(result).Kind() != reflect.Ptr ||
+		reflect.TypeOf(result).Elem().Kind() != reflect.Slice {
+		return nil, ErrNotSliceAddress
+	}
+	if selector == nil {
+		selector = bson.M{}
+	}
+	sortByID := len(opts.Sort) == 0 || len(opts.Sort) == 1 && opts.Sort[0] == "_id"
+	query := selector
+	if opts.After != "" {
+		if !sortByID {
+			return nil, ErrCursorNotSortedByID
+		}
+		id, err := decodeCursor(opts.After)
+		if err != nil {
+			return nil, err
+		}
+		query = bson.M{"$and": []interface{}{selector, bson.M{"_id": bson.M{"$gt": id}}}}
+	}
+
+	var total int
+	err := c.runInto(ctx, result, func(s *mgo.Session, result interface{}) error {
+		coll := s.DB(database).C(collection)
+		n, err := withMaxTime(ctx, coll.Find(selector)).Count()
+		if err != nil {
+			return err
+		}
+
+		q := withMaxTime(ctx, coll.Find(query))
+		if sortByID {
+			q = q.Sort("_id")
+		} else {
+			q = q.Sort(opts.Sort...)
+		}
+		if opts.Projection != nil {
+			q = q.Select(opts.Projection)
+		}
+		if opts.Skip > 0 {
+			q = q.Skip(opts.Skip)
+		}
+		if opts.Limit > 0 {
+			q = q.Limit(opts.Limit)
+		}
+		if err := q.All(result); err != nil {
+			return err
+		}
+		total = n
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	page := &Page{Items: result, Total: total}
+
+	if items := reflect.ValueOf(result).Elem(); sortByID && opts.Limit > 0 && items.Len() == opts.Limit {
+		page.NextCursor = encodeCursor(items.Index(items.Len() - 1).Interface())
+	}
+	return page, nil
+}
+
+// encodeCursor returns the cursor
+// token pointing to the _id of
+// 'item'. An empty token is returned
+// when 'item' has no _id (e.g. it
+// was excluded by the projection).
+func encodeCursor(item interface{}) string {
+	raw, err := bson.Marshal(item)
+	if err != nil {
+		return ""
+	}
+	var doc bson.M
+	if err := bson.Unmarshal(raw, &doc); err != nil {
+		return ""
+	}
+	id, ok := doc["_id"]
+	if !ok {
+		return ""
+	}
+	raw, err = bson.Marshal(bson.M{"_id": id})
+	if err != nil {
+		return ""
+	}
+	return base64.RawURLEncoding.EncodeToString(raw)
+}
+
+// decodeCursor returns the _id
+// which the cursor token points to.
+func decodeCursor(token string) (interface{}, error) {
+	raw, err := base64.RawURLEncoding.DecodeString(token)
+	if err != nil {
+		return nil, ErrInvalidCursor
+	}
+	var doc bson.M
+	if err := bson.Unmarshal(raw, &doc); err != nil {
+		return nil, ErrInvalidCursor
+	}
+	id, ok := doc["_id"]
+	if !ok {
+		return nil, ErrInvalidCursor
+	}
+	return id, nil
+}
+
+// End-of-file
@@ -69,3 +69,10 @@ func (p *pool) push(f func()) {
 func Push(f func()) {
 	worker.push(f)
 }
+
+// Available reports whether
+// the pool has been created
+// and not closed yet.
+func Available() bool {
+	return worker.available()
+}
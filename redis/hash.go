@@ -0,0 +1,73 @@
+package redis
+
+// HGet gets the value of the field
+// of the hash stored at the key.
+func HGet(key, field string) (string, error) {
+	v, err := redisClient.HGet(key, field).Result()
+	return v, notFound(err)
+}
+
+// HGetAll gets all the fields and
+// values of the hash stored at the
+// key. An empty map is returned
+// for a missing key.
+func HGetAll(key string) (map[string]string, error) {
+	return redisClient.HGetAll(key).Result()
+}
+
+// HMGet gets the values of the
+// fields of the hash stored at the
+// key. The value of a missing field
+// is nil.
+func HMGet(key string, fields ...string) ([]interface{}, error) {
+	return redisClient.HMGet(key, fields...).Result()
+}
+
+// HSet sets the value of the field
+// of the hash stored at the key, and
+// reports whether the field is new.
+func HSet(key, field string, value interface{}) (bool, error) {
+	return redisClient.HSet(key, field, value).Result()
+}
+
+// HMSet sets the values of the
+// fields of the hash stored at
+// the key.
+func HMSet(key string, fields map[string]interface{}) error {
+	return redisClient.HMSet(key, fields).Err()
+}
+
+// HDel removes the fields from the
+// hash stored at the key, and returns
+// the number of fields removed.
+func HDel(key string, fields ...string) (int64, error) {
+	return redisClient.HDel(key, fields...).Result()
+}
+
+// HExists reports whether the field
+// exists in the hash stored at the key.
+func HExists(key, field string) (bool, error) {
+	return redisClient.HExists(key, field).Result()
+}
+
+// HIncrBy increments the integer
+// value of the field of the hash
+// stored at the key by 'incr', and
+// returns the new value.
+func HIncrBy(key, field string, incr int64) (int64, error) {
+	return redisClient.HIncrBy(key, field, incr).Result()
+}
+
+// HKeys gets all the fields of the
+// hash stored at the key.
+func HKeys(key string) ([]string, error) {
+	return redisClient.HKeys(key).Result()
+}
+
+// HLen returns the number of fields
+// of the hash stored at the key.
+func HLen(key string) (int64, error) {
+	return redisClient.HLen(key).Result()
+}
+
+// End-of-file
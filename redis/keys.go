@@ -0,0 +1,94 @@
+package redis
+
+import (
+	// Native packages
+	"time"
+)
+
+// Del removes the keys and returns
+// the number of keys removed.
+func Del(keys ...string) (int64, error) {
+	return redisClient.Del(keys...).Result()
+}
+
+// Exists returns the number of
+// the given keys which exist.
+func Exists(keys ...string) (int64, error) {
+	return redisClient.Exists(keys...).Result()
+}
+
+// Expire sets the time to live of
+// the key, and reports whether the
+// key exists.
+func Expire(key string, expiration time.Duration) (bool, error) {
+	return redisClient.Expire(key, expiration).Result()
+}
+
+// ExpireAt sets the time when the
+// key expires, and reports whether
+// the key exists.
+func ExpireAt(key string, tm time.Time) (bool, error) {
+	return redisClient.ExpireAt(key, tm).Result()
+}
+
+// Persist removes the time to live
+// of the key, and reports whether
+// it had one.
+func Persist(key string) (bool, error) {
+	return redisClient.Persist(key).Result()
+}
+
+// TTL returns the remaining time
+// to live of the key. A negative
+// duration is returned for a key
+// without time to live, and
+// ErrNotFound for a missing key.
+func TTL(key string) (time.Duration, error) {
+	ttl, err := redisClient.TTL(key).Result()
+	if err != nil {
+		return 0, err
+	}
+	// go-redis returns -2 (in the unit
+	// of the command) for missing keys.
+	if ttl == -2*time.Second {
+		return 0, ErrNotFound
+	}
+	return ttl, nil
+}
+
+// Incr increments the integer value
+// of the key by one, and returns
+// the new value.
+func Incr(key string) (int64, error) {
+	return redisClient.Incr(key).Result()
+}
+
+// IncrBy increments the integer
+// value of the key by 'value', and
+// returns the new value.
+func IncrBy(key string, value int64) (int64, error) {
+	return redisClient.IncrBy(key, value).Result()
+}
+
+// IncrByFloat increments the float
+// value of the key by 'value', and
+// returns the new value.
+func IncrByFloat(key string, value float64) (float64, error) {
+	return redisClient.IncrByFloat(key, value).Result()
+}
+
+// Decr decrements the integer value
+// of the key by one, and returns
+// the new value.
+func Decr(key string) (int64, error) {
+	return redisClient.Decr(key).Result()
+}
+
+// DecrBy decrements the integer
+// value of the key by 'value', and
+// returns the new value.
+func DecrBy(key string, value int64) (int64, error) {
+	return redisClient.DecrBy(key, value).Result()
+}
+
+// End-of-file
@@ -0,0 +1,68 @@
+package redis
+
+// LPush inserts the values at the
+// head of the list stored at the key,
+// and returns the new length.
+func LPush(key string, values ...interface{}) (int64, error) {
+	return redisClient.LPush(key, values...).Result()
+}
+
+// RPush inserts the values at the
+// tail of the list stored at the key,
+// and returns the new length.
+func RPush(key string, values ...interface{}) (int64, error) {
+	return redisClient.RPush(key, values...).Result()
+}
+
+// LPop removes and returns the first
+// element of the list stored at the key.
+func LPop(key string) (string, error) {
+	v, err := redisClient.LPop(key).Result()
+	return v, notFound(err)
+}
+
+// RPop removes and returns the last
+// element of the list stored at the key.
+func RPop(key string) (string, error) {
+	v, err := redisClient.RPop(key).Result()
+	return v, notFound(err)
+}
+
+// LIndex gets the element at 'index'
+// of the list stored at the key.
+func LIndex(key string, index int64) (string, error) {
+	v, err := redisClient.LIndex(key, index).Result()
+	return v, notFound(err)
+}
+
+// LRange gets the elements from
+// 'start' to 'stop' (inclusive, -1
+// being the last element) of the
+// list stored at the key.
+func LRange(key string, start, stop int64) ([]string, error) {
+	return redisClient.LRange(key, start, stop).Result()
+}
+
+// LLen returns the length of the
+// list stored at the key.
+func LLen(key string) (int64, error) {
+	return redisClient.LLen(key).Result()
+}
+
+// LRem removes 'count' occurrences
+// of the value from the list stored
+// at the key (all of them when count
+// is zero), and returns the number
+// of elements removed.
+func LRem(key string, count int64, value interface{}) (int64, error) {
+	return redisClient.LRem(key, count, value).Result()
+}
+
+// LTrim trims the list stored at the
+// key to the elements from 'start'
+// to 'stop' (inclusive).
+func LTrim(key string, start, stop int64) error {
+	return redisClient.LTrim(key, start, stop).Err()
+}
+
+// End-of-file
@@ -0,0 +1,271 @@
+package redis
+
+import (
+	// Native packages
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	mrand "math/rand"
+	"sync"
+	"time"
+
+	// Third parties
+	"github.com/go-redis/redis"
+)
+
+var (
+	// ErrLockNotAcquired is returned
+	// when the lock is held by another
+	// holder until the context is done.
+	ErrLockNotAcquired = errors.New("redis: lock not acquired")
+
+	// ErrLockNotHeld is returned when
+	// the lock has expired or has been
+	// taken by another holder.
+	ErrLockNotHeld = errors.New("redis: lock not held")
+
+	// ErrInvalidLockTTL is returned
+	// when the TTL of a lock is less
+	// than a millisecond.
+	ErrInvalidLockTTL = errors.New("redis: lock ttl must be positive")
+
+	// releaseScript deletes the key
+	// only when it holds the token.
+	releaseScript = redis.NewScript(`
+if redis.call("get", KEYS[1]) == ARGV[1] then
+	return redis.call("del", KEYS[1])
+end
+return 0`)
+
+	// extendScript sets the TTL (in
+	// milliseconds) of the key only
+	// when it holds the token.
+	extendScript = redis.NewScript(`
+if redis.call("get", KEYS[1]) == ARGV[1] then
+	return redis.call("pexpire", KEYS[1], ARGV[2])
+end
+return 0`)
+)
+
+// LockOptions contains the options
+// for acquiring a lock.
+type LockOptions struct {
+	// MinBackoff is the delay before
+	// the first retry, doubled after
+	// every retry up to MaxBackoff.
+	// Zero means no retry.
+	MinBackoff time.Duration
+	MaxBackoff time.Duration
+	// AutoRefresh extends the lock
+	// every third of its TTL until
+	// it is released.
+	AutoRefresh bool
+}
+
+// DefaultLockOptions are the options
+// used by Acquire.
+var DefaultLockOptions = LockOptions{
+	MinBackoff:  50 * time.Millisecond,
+	MaxBackoff:  time.Second,
+	AutoRefresh: true,
+}
+
+// Lock is a lock held on a key,
+// identified by a unique token so
+// that only its holder can release
+// or extend it.
+type Lock struct {
+	key   string
+	token string
+
+	mux sync.Mutex
+	ttl time.Duration
+	// expiry closes done when the TTL
+	// runs out, without automatic
+	// refresh.
+	expiry   *time.Timer
+	stop     chan struct{}
+	done     chan struct{}
+	once     sync.Once
+	doneOnce sync.Once
+}
+
+// Acquire acquires the lock on the
+// key for 'ttl', retrying with
+// backoff until it is released by
+// its holder or 'ctx' is done. The
+// lock is refreshed automatically
+// until Release is called.
+// (Notice: With sentinels, the lock
+// is taken on the current master,
+// and can be lost on failover.)
+func Acquire(ctx context.Context, key string, ttl time.Duration) (*Lock, error) {
+	return AcquireWithOptions(ctx, key, ttl, DefaultLockOptions)
+}
+
+// AcquireWithOptions is the same
+// as Acquire, with the options
+// 'opts'.
+func AcquireWithOptions(ctx context.Context, key string, ttl time.Duration, opts LockOptions) (*Lock, error) {
+	// A key without TTL would never
+	// expire, and PEXPIRE 0 deletes it.
+	if ttl < time.Millisecond {
+		return nil, ErrInvalidLockTTL
+	}
+	token, err := newToken()
+	if err != nil {
+		return nil, err
+	}
+
+	backoff := opts.MinBackoff
+	for {
+		ok, err := redisClient.SetNX(key, token, ttl).Result()
+		if err != nil {
+			return nil, err
+		}
+		if ok {
+			break
+		}
+		if backoff <= 0 {
+			return nil, ErrLockNotAcquired
+		}
+		// Up to 50% of jitter, so that
+		// the waiting holders do not
+		// retry at the same time.
+		delay := backoff/2 + time.Duration(mrand.Int63n(int64(backoff/2)+1))
+		select {
+		case <-ctx.Done():
+			return nil, ErrLockNotAcquired
+		case <-time.After(delay):
+		}
+		if backoff *= 2; opts.MaxBackoff > 0 && backoff > opts.MaxBackoff {
+			backoff = opts.MaxBackoff
+		}
+	}
+
+	l := &Lock{key: key, token: token, ttl: ttl, stop: make(chan struct{}), done: make(chan struct{})}
+	if opts.AutoRefresh {
+		go l.refresh()
+	} else {
+		l.expiry = time.AfterFunc(ttl, l.finish)
+	}
+	return l, nil
+}
+
+// Key returns the locked key.
+func (l *Lock) Key() string {
+	return l.key
+}
+
+// Done returns a channel closed
+// when the lock is released, or
+// lost because the automatic
+// refresh failed or, without
+// automatic refresh, its TTL
+// ran out.
+func (l *Lock) Done() <-chan struct{} {
+	return l.done
+}
+
+// Release releases the lock. It
+// returns ErrLockNotHeld when the
+// lock has already expired.
+func (l *Lock) Release() error {
+	l.once.Do(func() { close(l.stop) })
+	l.mux.Lock()
+	if l.expiry != nil {
+		l.expiry.Stop()
+	}
+	l.mux.Unlock()
+	l.finish()
+	n, err := releaseScript.Run(redisClient, []string{l.key}, l.token).Int64()
+	if err != nil {
+		return err
+	}
+	if n == 0 {
+		return ErrLockNotHeld
+	}
+	return nil
+}
+
+// Extend sets the TTL of the lock
+// to 'ttl'. It returns ErrLockNotHeld
+// when the lock has already expired.
+func (l *Lock) Extend(ttl time.Duration) error {
+	if ttl < time.Millisecond {
+		return ErrInvalidLockTTL
+	}
+	l.mux.Lock()
+	l.ttl = ttl
+	l.mux.Unlock()
+	if err := l.extend(ttl); err != nil {
+		return err
+	}
+	l.mux.Lock()
+	if l.expiry != nil {
+		l.expiry.Reset(ttl)
+	}
+	l.mux.Unlock()
+	return nil
+}
+
+func (l *Lock) extend(ttl time.Duration) error {
+	n, err := extendScript.Run(redisClient, []string{l.key}, l.token, int64(ttl/time.Millisecond)).Int64()
+	if err != nil {
+		return err
+	}
+	if n == 0 {
+		return ErrLockNotHeld
+	}
+	return nil
+}
+
+// refresh extends the lock every
+// third of its TTL until it is
+// released or lost. The lock is
+// considered lost when it could not
+// be extended for its whole TTL
+// (e.g. redis-server is down), as
+// the key has expired meanwhile.
+func (l *Lock) refresh() {
+	defer l.finish()
+	extended := time.Now()
+	for {
+		l.mux.Lock()
+		ttl := l.ttl
+		l.mux.Unlock()
+		select {
+		case <-l.stop:
+			return
+		case <-time.After(ttl / 3):
+		}
+		err := l.extend(ttl)
+		if err == nil {
+			extended = time.Now()
+			continue
+		}
+		log.Warnf("Can not refresh the lock %s, error: %v", l.key, err)
+		if err == ErrLockNotHeld || time.Since(extended) >= ttl {
+			return
+		}
+	}
+}
+
+// finish closes the channel
+// returned by Done.
+func (l *Lock) finish() {
+	l.doneOnce.Do(func() { close(l.done) })
+}
+
+// newToken returns a random token
+// identifying the holder of a lock.
+func newToken() (string, error) {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b), nil
+}
+
+// End-of-file
@@ -0,0 +1,49 @@
+package redis
+
+import (
+	// Third parties
+	"github.com/go-redis/redis"
+)
+
+// Pipeliner queues the commands
+// of a pipeline. The results are
+// available from the queued
+// commands once it has run.
+type Pipeliner = redis.Pipeliner
+
+// Pipeline sends all the commands
+// queued by 'fn' at once, which saves
+// the round trips to redis-server.
+// The first failed command is
+// returned as error (missing keys
+// are not errors in pipelines).
+func Pipeline(fn func(p Pipeliner) error) error {
+	return pipelineErr(redisClient.Pipelined(fn))
+}
+
+// TxPipeline is the same as
+// Pipeline, but the commands are
+// wrapped in MULTI/EXEC so they
+// are executed atomically.
+func TxPipeline(fn func(p Pipeliner) error) error {
+	return pipelineErr(redisClient.TxPipelined(fn))
+}
+
+// pipelineErr returns the first error
+// of the commands which is not a
+// missing key. go-redis returns the
+// first error, which may hide a real
+// failure behind a missing key.
+func pipelineErr(cmds []redis.Cmder, err error) error {
+	if err != redis.Nil {
+		return err
+	}
+	for _, cmd := range cmds {
+		if err := cmd.Err(); err != nil && err != redis.Nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// End-of-file
@@ -0,0 +1,98 @@
+package redis
+
+import (
+	// Native packages
+	"context"
+	"encoding/json"
+
+	// Third parties
+	"github.com/go-redis/redis"
+)
+
+// Message is a message
+// received from a channel.
+type Message struct {
+	Channel string
+	Payload []byte
+}
+
+// Decode decodes the JSON
+// payload of the message into 'v'.
+func (m *Message) Decode(v interface{}) error {
+	return json.Unmarshal(m.Payload, v)
+}
+
+// Handler handles the messages
+// received by a subscription.
+type Handler func(ctx context.Context, msg *Message) error
+
+// Subscription receives the messages
+// of channels until it is closed.
+type Subscription struct {
+	pubsub *redis.PubSub
+	done   chan struct{}
+}
+
+// Publish encodes 'payload' to JSON
+// and publishes it to the channel.
+// It returns the number of
+// subscribers which received it.
+func Publish(channel string, payload interface{}) (int64, error) {
+	data, err := json.Marshal(payload)
+	if err != nil {
+		return 0, err
+	}
+	return redisClient.Publish(channel, data).Result()
+}
+
+// Subscribe subscribes to the channels
+// and calls 'handler' for every message
+// received, in order, until 'ctx' is
+// done or the subscription is closed.
+// The errors of the handler are logged.
+func Subscribe(ctx context.Context, handler Handler, channels ...string) (*Subscription, error) {
+	ps := redisClient.Subscribe(channels...)
+	// Wait for the confirmation,
+	// so no message published after
+	// Subscribe returns is missed.
+	if _, err := ps.Receive(); err != nil {
+		_ = ps.Close()
+		return nil, err
+	}
+
+	s := &Subscription{pubsub: ps, done: make(chan struct{})}
+	go func() {
+		defer close(s.done)
+		ch := ps.Channel()
+		for {
+			select {
+			case <-ctx.Done():
+				_ = ps.Close()
+				return
+			case m, ok := <-ch:
+				if !ok {
+					return
+				}
+				msg := &Message{Channel: m.Channel, Payload: []byte(m.Payload)}
+				if err := handler(ctx, msg); err != nil {
+					log.TWarnf(ctx, "Can not handle the message of channel %s, error: %v", m.Channel, err)
+				}
+			}
+		}
+	}()
+	return s, nil
+}
+
+// Done returns a channel closed
+// when the subscription stops.
+func (s *Subscription) Done() <-chan struct{} {
+	return s.done
+}
+
+// Close unsubscribes from
+// the channels.
+func (s *Subscription) Close() error {
+	return s.pubsub.Close()
+}
+
+// End-of-file
@@ -0,0 +1,90 @@
+package redis
+
+import (
+	// Native packages
+	"errors"
+	"fmt"
+	"math/rand"
+	"time"
+
+	// Third parties
+	"github.com/go-redis/redis"
+)
+
+// ErrInvalidRateLimit is returned
+// when the limit or the window
+// (in milliseconds) is not positive.
+var ErrInvalidRateLimit = errors.New("redis: rate limit and window must be positive")
+
+// slidingWindowScript counts the hits
+// of the key in the last window (in
+// milliseconds) with a sorted set,
+// and adds the hit when it is under
+// the limit. It returns whether the
+// hit is allowed, the remaining hits
+// and the time until the oldest hit
+// leaves the window.
+var slidingWindowScript = redis.NewScript(`
+local now = tonumber(ARGV[1])
+local window = tonumber(ARGV[2])
+local limit = tonumber(ARGV[3])
+redis.call("zremrangebyscore", KEYS[1], "-inf", now - window)
+local count = redis.call("zcard", KEYS[1])
+local allowed = 0
+if count < limit then
+	redis.call("zadd", KEYS[1], now, ARGV[4])
+	redis.call("pexpire", KEYS[1], window)
+	count = count + 1
+	allowed = 1
+end
+local reset = window
+local oldest = redis.call("zrange", KEYS[1], 0, 0, "withscores")
+if oldest[2] then
+	reset = tonumber(oldest[2]) + window - now
+end
+return {allowed, limit - count, reset}`)
+
+// RateLimit is the result of
+// a rate limited hit.
+type RateLimit struct {
+	// Allowed reports whether the
+	// hit is under the limit.
+	Allowed bool
+	// Remaining is the number of
+	// hits left in the window.
+	Remaining int64
+	// Reset is the time until a
+	// hit leaves the window.
+	Reset time.Duration
+}
+
+// SlidingWindow counts a hit on the
+// key and reports whether there are
+// at most 'limit' hits in the last
+// 'window', across all the replicas
+// using the same redis-server.
+func SlidingWindow(key string, limit int64, window time.Duration) (RateLimit, error) {
+	if limit <= 0 || window < time.Millisecond {
+		return RateLimit{}, ErrInvalidRateLimit
+	}
+	now := time.Now().UnixNano() / int64(time.Millisecond)
+	member := fmt.Sprintf("%d-%d", now, rand.Int63())
+	res, err := slidingWindowScript.Run(redisClient, []string{key}, now, int64(window/time.Millisecond), limit, member).Result()
+	if err != nil {
+		return RateLimit{}, err
+	}
+	values, ok := res.([]interface{})
+	if !ok || len(values) != 3 {
+		return RateLimit{}, fmt.Errorf("redis: unexpected reply %v", res)
+	}
+	allowed, _ := values[0].(int64)
+	remaining, _ := values[1].(int64)
+	reset, _ := values[2].(int64)
+	return RateLimit{
+		Allowed:   allowed == 1,
+		Remaining: remaining,
+		Reset:     time.Duration(reset) * time.Millisecond,
+	}, nil
+}
+
+// End-of-file
@@ -2,40 +2,156 @@ package redis
 
 import (
 	// Native packages
+	"context"
+	"crypto/tls"
+	"errors"
 	"time"
 
 	// Third parties
 	"github.com/go-redis/redis"
+
+	// Internal packages
+	"github.com/tinwoan-go/basic-api/tlog"
 )
 
 var (
 	redisClient redis.UniversalClient
+
+	log tlog.Logger
+
+	// ErrNotFound is returned when
+	// the key (or the field, member,
+	// element) does not exist.
+	ErrNotFound = errors.New("redis: key not found")
+
+	// ErrInitialized is returned when
+	// the connection to redis-server
+	// has not been initialized.
+	ErrInitialized = errors.New("redis: connection has not been initialized")
 )
 
+func init() {
+	log = tlog.WithPrefix("redis")
+}
+
 // Configs contains the configuration
 // for opening connection to Redis server.
+// Zero values mean the defaults of
+// go-redis.
 type Configs struct {
 	Addresses []string
 	Master    string
 	Password  string
+	// DB is the index of the database
+	// (ignored by clusters).
+	DB int
+	// PoolSize is the maximum number
+	// of connections per node.
+	PoolSize int
+	// MinIdleConns is the minimum
+	// number of idle connections.
+	MinIdleConns int
+	DialTimeout  time.Duration
+	ReadTimeout  time.Duration
+	WriteTimeout time.Duration
+	// MaxRetries is the maximum number
+	// of retries of failed commands,
+	// with a backoff between
+	// MinRetryBackoff and
+	// MaxRetryBackoff.
+	MaxRetries      int
+	MinRetryBackoff time.Duration
+	MaxRetryBackoff time.Duration
+	// TLS enables TLS with the system
+	// root certificates. TLSConfig can
+	// be set instead for custom
+	// certificates.
+	TLS       bool
+	TLSConfig *tls.Config
 }
 
 // NewRedisClient creates an instance
 // of redis-client, which allow you
 // to get data from redis, also set
-// new data to it.
-func NewRedisClient(cfg Configs) {
-	redisClient = redis.NewUniversalClient(&redis.UniversalOptions{
-		MasterName: cfg.Master,
-		Addrs:      cfg.Addresses,
-		Password:   cfg.Password,
+// new data to it. The server is
+// pinged, and an error is returned
+// when it can not be reached.
+func NewRedisClient(cfg Configs) error {
+	tlsConfig := cfg.TLSConfig
+	if tlsConfig == nil && cfg.TLS {
+		tlsConfig = &tls.Config{}
+	}
+	c := redis.NewUniversalClient(&redis.UniversalOptions{
+		MasterName:      cfg.Master,
+		Addrs:           cfg.Addresses,
+		Password:        cfg.Password,
+		DB:              cfg.DB,
+		PoolSize:        cfg.PoolSize,
+		MinIdleConns:    cfg.MinIdleConns,
+		DialTimeout:     cfg.DialTimeout,
+		ReadTimeout:     cfg.ReadTimeout,
+		WriteTimeout:    cfg.WriteTimeout,
+		MaxRetries:      cfg.MaxRetries,
+		MinRetryBackoff: cfg.MinRetryBackoff,
+		MaxRetryBackoff: cfg.MaxRetryBackoff,
+		TLSConfig:       tlsConfig,
 	})
+	if err := c.Ping().Err(); err != nil {
+		_ = c.Close()
+		return err
+	}
+	redisClient = c
+	return nil
+}
+
+// Available reports whether the
+// connection to redis-server has
+// been opened by NewRedisClient.
+func Available() bool {
+	return redisClient != nil
+}
+
+// Ping pings the redis-server, for
+// health checks. It returns when the
+// server answers or 'ctx' is done,
+// or ErrInitialized when the
+// connection has not been opened.
+func Ping(ctx context.Context) error {
+	if !Available() {
+		return ErrInitialized
+	}
+	done := make(chan error, 1)
+	go func() {
+		done <- redisClient.Ping().Err()
+	}()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case err := <-done:
+		return err
+	}
 }
 
 // Get gets value from
 // redis-server with a given key.
 func Get(key string) (string, error) {
-	return redisClient.Get(key).Result()
+	v, err := redisClient.Get(key).Result()
+	return v, notFound(err)
+}
+
+// GetBytes gets the raw value
+// from redis-server with a given key.
+func GetBytes(key string) ([]byte, error) {
+	v, err := redisClient.Get(key).Bytes()
+	return v, notFound(err)
+}
+
+// GetInt64 gets the value from
+// redis-server with a given key
+// and parses it as an integer.
+func GetInt64(key string) (int64, error) {
+	v, err := redisClient.Get(key).Int64()
+	return v, notFound(err)
 }
 
 // Set sets the value
@@ -45,9 +161,29 @@ func Set(key string, value interface{}, expiration time.Duration) (string, error
 	return redisClient.Set(key, value, expiration).Result()
 }
 
+// SetNX sets the value into
+// redis-server only when the key
+// does not exist yet, and reports
+// whether the value has been set.
+func SetNX(key string, value interface{}, expiration time.Duration) (bool, error) {
+	return redisClient.SetNX(key, value, expiration).Result()
+}
+
 // Close closes the connection
 // to the redis-server based on the
 // current instance in application.
 func Close() error {
 	return redisClient.Close()
 }
+
+// notFound converts redis.Nil,
+// returned by go-redis for the
+// missing keys, to ErrNotFound.
+func notFound(err error) error {
+	if err == redis.Nil {
+		return ErrNotFound
+	}
+	return err
+}
+
+// End-of-file
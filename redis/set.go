@@ -0,0 +1,44 @@
+package redis
+
+// SAdd adds the members to the set
+// stored at the key, and returns the
+// number of members added.
+func SAdd(key string, members ...interface{}) (int64, error) {
+	return redisClient.SAdd(key, members...).Result()
+}
+
+// SRem removes the members from the
+// set stored at the key, and returns
+// the number of members removed.
+func SRem(key string, members ...interface{}) (int64, error) {
+	return redisClient.SRem(key, members...).Result()
+}
+
+// SMembers gets all the members of
+// the set stored at the key.
+func SMembers(key string) ([]string, error) {
+	return redisClient.SMembers(key).Result()
+}
+
+// SIsMember reports whether the
+// member belongs to the set stored
+// at the key.
+func SIsMember(key string, member interface{}) (bool, error) {
+	return redisClient.SIsMember(key, member).Result()
+}
+
+// SCard returns the number of
+// members of the set stored at
+// the key.
+func SCard(key string) (int64, error) {
+	return redisClient.SCard(key).Result()
+}
+
+// SPop removes and returns a random
+// member of the set stored at the key.
+func SPop(key string) (string, error) {
+	v, err := redisClient.SPop(key).Result()
+	return v, notFound(err)
+}
+
+// End-of-file
@@ -0,0 +1,253 @@
+package redis
+
+import (
+	// Native packages
+	"context"
+	"encoding/json"
+	"strings"
+	"sync"
+	"time"
+
+	// Third parties
+	"github.com/go-redis/redis"
+
+	// Internal packages
+	"github.com/tinwoan-go/basic-api/pool"
+)
+
+// payloadField is the field of the
+// stream entries holding the JSON
+// payload.
+const payloadField = "payload"
+
+// StreamMessage is an entry
+// read from a stream.
+type StreamMessage struct {
+	ID     string
+	Stream string
+	Values map[string]interface{}
+}
+
+// Decode decodes the JSON payload
+// added by XAdd into 'v'.
+func (m *StreamMessage) Decode(v interface{}) error {
+	payload, ok := m.Values[payloadField].(string)
+	if !ok {
+		return ErrNotFound
+	}
+	return json.Unmarshal([]byte(payload), v)
+}
+
+// StreamHandler handles the entries
+// read by a consumer. The entry is
+// acknowledged when it returns nil.
+type StreamHandler func(ctx context.Context, msg *StreamMessage) error
+
+// XAdd encodes 'payload' to JSON and
+// appends it to the stream, which is
+// trimmed to about 'maxLen' entries
+// when 'maxLen' is positive. It
+// returns the ID of the entry.
+func XAdd(stream string, maxLen int64, payload interface{}) (string, error) {
+	data, err := json.Marshal(payload)
+	if err != nil {
+		return "", err
+	}
+	return redisClient.XAdd(&redis.XAddArgs{
+		Stream:       stream,
+		MaxLenApprox: maxLen,
+		Values:       map[string]interface{}{payloadField: data},
+	}).Result()
+}
+
+// ConsumerOptions contains the
+// options of a stream consumer.
+type ConsumerOptions struct {
+	// Group is the consumer group,
+	// created if it does not exist.
+	Group string
+	// Consumer is the name of the
+	// consumer, unique in the group
+	// (e.g. the hostname).
+	Consumer string
+	// Count is the maximum number of
+	// entries read at once. Zero
+	// means 10.
+	Count int64
+	// Block is the maximum time to
+	// wait for new entries. Zero
+	// means 5 seconds.
+	Block time.Duration
+	// MinIdle is the time from which
+	// the entries delivered to a
+	// consumer but not acknowledged
+	// (e.g. it crashed) are claimed
+	// by this consumer. Zero means
+	// one minute.
+	MinIdle time.Duration
+}
+
+// Consumer reads the entries of a
+// stream as a member of a consumer
+// group.
+type Consumer struct {
+	stream string
+	opts   ConsumerOptions
+
+	// inflight holds the IDs of the
+	// entries dispatched and not
+	// handled yet, which must not
+	// be claimed again.
+	mux      sync.Mutex
+	inflight map[string]bool
+}
+
+// NewConsumer creates a consumer
+// of the stream, creating the
+// stream and the group if needed.
+// New groups start with the entries
+// added after their creation.
+func NewConsumer(stream string, opts ConsumerOptions) (*Consumer, error) {
+	if opts.Count <= 0 {
+		opts.Count = 10
+	}
+	if opts.Block <= 0 {
+		opts.Block = 5 * time.Second
+	}
+	if opts.MinIdle <= 0 {
+		opts.MinIdle = time.Minute
+	}
+	err := redisClient.XGroupCreateMkStream(stream, opts.Group, "$").Err()
+	if err != nil && !strings.HasPrefix(err.Error(), "BUSYGROUP") {
+		return nil, err
+	}
+	return &Consumer{stream: stream, opts: opts, inflight: map[string]bool{}}, nil
+}
+
+// Run reads the entries of the stream
+// and runs 'handler' for each of them
+// on the workers of the package pool
+// (or inline when the pool has not
+// been created), until 'ctx' is done.
+// The pending entries idle for more
+// than MinIdle are claimed and handled
+// again every MinIdle.
+func (c *Consumer) Run(ctx context.Context, handler StreamHandler) error {
+	lastReclaim := time.Time{}
+	for {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		default:
+		}
+
+		if time.Since(lastReclaim) >= c.opts.MinIdle {
+			lastReclaim = time.Now()
+			if err := c.reclaim(ctx, handler); err != nil {
+				log.TWarnf(ctx, "Can not claim the pending entries of stream %s, error: %v", c.stream, err)
+			}
+		}
+
+		streams, err := redisClient.XReadGroup(&redis.XReadGroupArgs{
+			Group:    c.opts.Group,
+			Consumer: c.opts.Consumer,
+			Streams:  []string{c.stream, ">"},
+			Count:    c.opts.Count,
+			Block:    c.opts.Block,
+		}).Result()
+		switch {
+		case err == redis.Nil:
+			continue
+		case err != nil:
+			log.TWarnf(ctx, "Can not read stream %s, error: %v", c.stream, err)
+			select {
+			case <-ctx.Done():
+				return ctx.Err()
+			case <-time.After(time.Second):
+			}
+			continue
+		}
+		for _, s := range streams {
+			c.dispatch(ctx, handler, s.Messages)
+		}
+	}
+}
+
+// reclaim claims the entries pending
+// for more than MinIdle and handles
+// them, except the entries of this
+// consumer still waiting in the
+// queue of the pool or being
+// handled.
+func (c *Consumer) reclaim(ctx context.Context, handler StreamHandler) error {
+	pending, err := redisClient.XPendingExt(&redis.XPendingExtArgs{
+		Stream: c.stream,
+		Group:  c.opts.Group,
+		Start:  "-",
+		End:    "+",
+		Count:  c.opts.Count,
+	}).Result()
+	if err != nil {
+		return err
+	}
+	var ids []string
+	c.mux.Lock()
+	for _, p := range pending {
+		if p.Idle >= c.opts.MinIdle && !c.inflight[p.Id] {
+			ids = append(ids, p.Id)
+		}
+	}
+	c.mux.Unlock()
+	if len(ids) == 0 {
+		return nil
+	}
+	msgs, err := redisClient.XClaim(&redis.XClaimArgs{
+		Stream:   c.stream,
+		Group:    c.opts.Group,
+		Consumer: c.opts.Consumer,
+		MinIdle:  c.opts.MinIdle,
+		Messages: ids,
+	}).Result()
+	if err != nil {
+		return err
+	}
+	c.dispatch(ctx, handler, msgs)
+	return nil
+}
+
+// dispatch runs 'handler' for the
+// entries and acknowledges the
+// entries handled successfully.
+func (c *Consumer) dispatch(ctx context.Context, handler StreamHandler, msgs []redis.XMessage) {
+	for _, m := range msgs {
+		msg := &StreamMessage{ID: m.ID, Stream: c.stream, Values: m.Values}
+		c.mux.Lock()
+		if c.inflight[msg.ID] {
+			c.mux.Unlock()
+			continue
+		}
+		c.inflight[msg.ID] = true
+		c.mux.Unlock()
+		f := func() {
+			defer func() {
+				c.mux.Lock()
+				delete(c.inflight, msg.ID)
+				c.mux.Unlock()
+			}()
+			if err := handler(ctx, msg); err != nil {
+				log.TWarnf(ctx, "Can not handle the entry %s of stream %s, error: %v", msg.ID, c.stream, err)
+				return
+			}
+			if err := redisClient.XAck(c.stream, c.opts.Group, msg.ID).Err(); err != nil {
+				log.TWarnf(ctx, "Can not acknowledge the entry %s of stream %s, error: %v", msg.ID, c.stream, err)
+			}
+		}
+		if pool.Available() {
+			pool.Push(f)
+		} else {
+			f()
+		}
+	}
+}
+
+// End-of-file
@@ -0,0 +1,98 @@
+package redis
+
+import (
+	// Third parties
+	"github.com/go-redis/redis"
+)
+
+// Z is a member of a
+// sorted set with its score.
+type Z = redis.Z
+
+// ZAdd adds the members to the
+// sorted set stored at the key (or
+// updates their scores), and returns
+// the number of members added.
+func ZAdd(key string, members ...Z) (int64, error) {
+	return redisClient.ZAdd(key, members...).Result()
+}
+
+// ZRem removes the members from the
+// sorted set stored at the key, and
+// returns the number of members
+// removed.
+func ZRem(key string, members ...interface{}) (int64, error) {
+	return redisClient.ZRem(key, members...).Result()
+}
+
+// ZScore gets the score of the
+// member of the sorted set stored
+// at the key.
+func ZScore(key, member string) (float64, error) {
+	v, err := redisClient.ZScore(key, member).Result()
+	return v, notFound(err)
+}
+
+// ZIncrBy increments the score of
+// the member of the sorted set stored
+// at the key, and returns the new score.
+func ZIncrBy(key string, increment float64, member string) (float64, error) {
+	return redisClient.ZIncrBy(key, increment, member).Result()
+}
+
+// ZRank gets the rank (from the
+// lowest score, starting at zero)
+// of the member of the sorted set
+// stored at the key.
+func ZRank(key, member string) (int64, error) {
+	v, err := redisClient.ZRank(key, member).Result()
+	return v, notFound(err)
+}
+
+// ZCard returns the number of
+// members of the sorted set stored
+// at the key.
+func ZCard(key string) (int64, error) {
+	return redisClient.ZCard(key).Result()
+}
+
+// ZRange gets the members from
+// 'start' to 'stop' (inclusive, -1
+// being the last member) of the sorted
+// set stored at the key, by ascending
+// scores.
+func ZRange(key string, start, stop int64) ([]string, error) {
+	return redisClient.ZRange(key, start, stop).Result()
+}
+
+// ZRevRange is the same as ZRange,
+// but by descending scores.
+func ZRevRange(key string, start, stop int64) ([]string, error) {
+	return redisClient.ZRevRange(key, start, stop).Result()
+}
+
+// ZRangeWithScores is the same as
+// ZRange, with the scores of
+// the members.
+func ZRangeWithScores(key string, start, stop int64) ([]Z, error) {
+	return redisClient.ZRangeWithScores(key, start, stop).Result()
+}
+
+// ZRangeByScore gets the members
+// whose scores are between 'min' and
+// 'max' (e.g. "-inf", "(1.5", "10").
+// A positive 'count' limits the number
+// of members, after skipping 'offset'.
+func ZRangeByScore(key, min, max string, offset, count int64) ([]string, error) {
+	return redisClient.ZRangeByScore(key, redis.ZRangeBy{Min: min, Max: max, Offset: offset, Count: count}).Result()
+}
+
+// ZRemRangeByScore removes the
+// members whose scores are between
+// 'min' and 'max', and returns the
+// number of members removed.
+func ZRemRangeByScore(key, min, max string) (int64, error) {
+	return redisClient.ZRemRangeByScore(key, min, max).Result()
+}
+
+// End-of-file
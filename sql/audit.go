@@ -0,0 +1,184 @@
+package sql
+
+import (
+	// Native packages
+	"context"
+	"reflect"
+	"time"
+
+	// Third parties
+	"github.com/jinzhu/gorm"
+)
+
+// Columns filled by the soft delete
+// and audit modes of a client.
+const (
+	DeletedAtColumn = "deleted_at"
+	CreatedAtColumn = "created_at"
+	UpdatedAtColumn = "updated_at"
+	UpdatedByColumn = "updated_by"
+)
+
+// Settings of the gorm handles which
+// carry the modes of the client and
+// the actor down to the helpers,
+// including inside transactions.
+const (
+	softDeleteSetting = "basic-api:soft_delete"
+	auditSetting      = "basic-api:audit"
+	actorSetting      = "basic-api:actor"
+)
+
+// contextKey is the type of the
+// keys of values put in contexts
+// by this package.
+type contextKey string
+
+const actorKey contextKey = "SQLActor"
+
+// WithActor returns a copy of 'ctx'
+// carrying 'actor', which is stamped
+// as updated_by by the helpers of
+// clients in audit mode. It is
+// typically called by a middleware
+// after authenticating the request.
+func WithActor(ctx context.Context, actor string) context.Context {
+	return context.WithValue(ctx, actorKey, actor)
+}
+
+// ActorFromContext returns the actor
+// carried by 'ctx', if any.
+func ActorFromContext(ctx context.Context) string {
+	actor, _ := ctx.Value(actorKey).(string)
+	return actor
+}
+
+// withModes returns 'handle' with
+// the soft delete and audit modes.
+func withModes(handle *gorm.DB, softDelete, audit bool) *gorm.DB {
+	return handle.Set(softDeleteSetting, softDelete).Set(auditSetting, audit)
+}
+
+// copySettings copies the modes and
+// the actor from 'src' to 'dst', and
+// sets the actor of 'ctx' if any.
+func copySettings(ctx context.Context, src, dst *gorm.DB) *gorm.DB {
+	for _, key := range []string{softDeleteSetting, auditSetting, actorSetting} {
+		if value, ok := src.Get(key); ok {
+			dst = dst.Set(key, value)
+		}
+	}
+	if actor := ActorFromContext(ctx); actor != "" {
+		dst = dst.Set(actorSetting, actor)
+	}
+	return dst
+}
+
+func setting(handle *gorm.DB, key string) bool {
+	value, ok := handle.Get(key)
+	if !ok {
+		return false
+	}
+	enabled, _ := value.(bool)
+	return enabled
+}
+
+func softDeleted(handle *gorm.DB) bool {
+	return setting(handle, softDeleteSetting)
+}
+
+func audited(handle *gorm.DB) bool {
+	return setting(handle, auditSetting)
+}
+
+// notDeleted filters out the soft
+// deleted records from 'q'.
+func notDeleted(q *gorm.DB) *gorm.DB {
+	return q.Where(q.NewScope(nil).Quote(DeletedAtColumn) + " IS NULL")
+}
+
+// auditColumns returns the audit
+// columns to stamp when creating
+// or updating records, or nil when
+// the audit mode is disabled.
+func auditColumns(handle *gorm.DB, creating bool) map[string]interface{} {
+	if !audited(handle) {
+		return nil
+	}
+	now := time.Now()
+	columns := map[string]interface{}{UpdatedAtColumn: now}
+	if creating {
+		columns[CreatedAtColumn] = now
+	}
+	if actor, ok := handle.Get(actorSetting); ok {
+		columns[UpdatedByColumn] = actor
+	}
+	return columns
+}
+
+// columnsOf returns the columns
+// updated by 'updater', which is a
+// map of columns, or a struct whose
+// non-blank fields are updated.
+func columnsOf(handle *gorm.DB, updater interface{}) (map[string]interface{}, error) {
+	columns := map[string]interface{}{}
+	if m, ok := updater.(map[string]interface{}); ok {
+		for column, value := range m {
+			columns[column] = value
+		}
+		return columns, nil
+	}
+	v := reflect.Indirect(reflect.ValueOf(updater))
+	if v.Kind() != reflect.Struct {
+		return nil, ErrNotSliceOrStructPtr
+	}
+	if !v.CanAddr() {
+		ptr := reflect.New(v.Type())
+		ptr.Elem().Set(v)
+		v = ptr.Elem()
+	}
+	for _, field := range handle.NewScope(v.Addr().Interface()).Fields() {
+		if field.IsNormal && !field.IsIgnored && !field.IsBlank {
+			columns[field.DBName] = field.Field.Interface()
+		}
+	}
+	return columns, nil
+}
+
+// createAudited creates the record
+// 'data' with the audit columns. The
+// columns which are not fields of the
+// struct are stamped by updating the
+// created record by its primary key,
+// so it must run inside a transaction.
+func createAudited(handle *gorm.DB, table string, data interface{}) error {
+	columns := auditColumns(handle, true)
+	scope := handle.NewScope(data)
+	for column, value := range columns {
+		field, ok := scope.FieldByName(column)
+		if !ok {
+			continue
+		}
+		if column == UpdatedByColumn || field.IsBlank {
+			if err := field.Set(value); err != nil {
+				return err
+			}
+		}
+		delete(columns, column)
+	}
+	if err := handle.Table(table).Create(data).Error; err != nil {
+		return err
+	}
+	if len(columns) == 0 {
+		return nil
+	}
+	// New scope for the primary key
+	// generated by the server.
+	pk := handle.NewScope(data).PrimaryField()
+	if pk == nil || pk.IsBlank {
+		return ErrNoPrimaryKey
+	}
+	return handle.Table(table).Where(scope.Quote(pk.DBName)+" = ?", pk.Field.Interface()).UpdateColumns(columns).Error
+}
+
+// End-of-file
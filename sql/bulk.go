@@ -0,0 +1,264 @@
+package sql
+
+import (
+	// Native packages
+	"context"
+	"reflect"
+	"sort"
+	"strings"
+	"time"
+
+	// Third parties
+	"github.com/jinzhu/gorm"
+)
+
+// defaultChunkSize is the default
+// number of rows per INSERT statement.
+const defaultChunkSize = 500
+
+// maxBindVars holds the maximum number
+// of parameters of one statement by
+// dialect, which limits the number of
+// rows per chunk.
+var maxBindVars = map[string]int{
+	"mssql":    2100,
+	"sqlite3":  999,
+	"postgres": 65535,
+	"mysql":    65535,
+}
+
+// BulkOptions contains the options
+// for inserting many records at once.
+type BulkOptions struct {
+	// ChunkSize is the maximum number
+	// of rows per INSERT statement.
+	// Zero means 500 rows. It is also
+	// limited by the maximum number of
+	// parameters of the server.
+	ChunkSize int
+	// OnConflict sets the behavior when
+	// a row conflicts with an existing
+	// one. Nil means failing the insert.
+	OnConflict *OnConflict
+}
+
+// OnConflict contains the upsert
+// options of a bulk insert.
+// (Notice: Upsert is supported for
+// postgres, mysql and sqlite3 only.)
+type OnConflict struct {
+	// Columns is the conflict target
+	// (unique columns). It is ignored
+	// by mysql, which uses any unique
+	// index.
+	Columns []string
+	// DoNothing skips the conflicting
+	// rows.
+	DoNothing bool
+	// Update contains the columns
+	// updated with the inserted values
+	// on conflict.
+	Update []string
+}
+
+// BulkInsert creates all the records
+// of 'data', which must be a slice or
+// a pointer of a slice of structs,
+// with multi-row INSERT statements.
+// All the chunks are inserted inside
+// a transaction. BulkInsert returns
+// the number of rows affected.
+func BulkInsert(table string, data interface{}, opts BulkOptions) (int64, error) {
+	return defaultClient.BulkInsert(table, data, opts)
+}
+
+// BulkInsert creates all the records
+// of 'data' with multi-row INSERT
+// statements on the primary database
+// of the client.
+func (c *Client) BulkInsert(table string, data interface{}, opts BulkOptions) (int64, error) {
+	return c.BulkInsertCtx(context.Background(), table, data, opts)
+}
+
+// BulkInsert creates all the records
+// of 'data' with multi-row INSERT
+// statements inside the transaction.
+func (tx *Tx) BulkInsert(table string, data interface{}, opts BulkOptions) (int64, error) {
+	return bulkInsert(tx.db, table, data, opts)
+}
+
+func bulkInsert(handle *gorm.DB, table string, data interface{}, opts BulkOptions) (int64, error) {
+	v := reflect.Indirect(reflect.ValueOf(data))
+	if v.Kind() != reflect.Slice {
+		return 0, ErrNotSlice
+	}
+	if v.Len() == 0 {
+		return 0, nil
+	}
+
+	// Collect the fields of every row
+	// and stamp the blank CreatedAt and
+	// UpdatedAt the same way as Create.
+	// In audit mode, the audit columns
+	// which are not fields of the struct
+	// are added to every row.
+	now := time.Now()
+	stamps := auditColumns(handle, true)
+	var extra []string
+	rows := make([][]*gorm.Field, v.Len())
+	for i := range rows {
+		elem := reflect.Indirect(v.Index(i))
+		if elem.Kind() != reflect.Struct {
+			return 0, ErrNotSliceOrStructPtr
+		}
+		rows[i] = handle.NewScope(elem.Addr().Interface()).Fields()
+		present := map[string]bool{}
+		for k, field := range rows[i] {
+			present[field.DBName] = true
+			value, ok := stamps[field.DBName]
+			switch {
+			case ok && (field.DBName == UpdatedByColumn || field.IsBlank):
+			case (field.Name == "CreatedAt" || field.Name == "UpdatedAt") && field.IsBlank:
+				value = now
+			default:
+				continue
+			}
+			stamped := *field
+			stamped.Field = reflect.ValueOf(value)
+			stamped.IsBlank = false
+			rows[i][k] = &stamped
+		}
+		if i == 0 {
+			for column := range stamps {
+				if !present[column] {
+					extra = append(extra, column)
+				}
+			}
+			sort.Strings(extra)
+		}
+		for _, column := range extra {
+			rows[i] = append(rows[i], &gorm.Field{
+				StructField: &gorm.StructField{Name: column, DBName: column, IsNormal: true},
+				Field:       reflect.ValueOf(stamps[column]),
+			})
+		}
+	}
+
+	// A column is skipped when it is
+	// blank in all the rows and filled
+	// by the server (auto increment
+	// primary key or default value).
+	var columns []int
+	for j, field := range rows[0] {
+		if !field.IsNormal || field.IsIgnored {
+			continue
+		}
+		if field.IsPrimaryKey || field.HasDefaultValue {
+			blank := true
+			for _, row := range rows {
+				blank = blank && row[j].IsBlank
+			}
+			if blank {
+				continue
+			}
+		}
+		columns = append(columns, j)
+	}
+	if len(columns) == 0 {
+		return 0, ErrNotSliceOrStructPtr
+	}
+
+	dialect := handle.Dialect().GetName()
+	chunkSize := opts.ChunkSize
+	if chunkSize <= 0 {
+		chunkSize = defaultChunkSize
+	}
+	if max, ok := maxBindVars[dialect]; ok && chunkSize*len(columns) > max {
+		chunkSize = max / len(columns)
+		if chunkSize == 0 {
+			chunkSize = 1
+		}
+	}
+
+	var count int64
+	for start := 0; start < len(rows); start += chunkSize {
+		end := start + chunkSize
+		if end > len(rows) {
+			end = len(rows)
+		}
+		scope := handle.NewScope(nil)
+		query, err := bulkInsertSQL(scope, dialect, table, rows[0], columns, rows[start:end], opts.OnConflict)
+		if err != nil {
+			return count, err
+		}
+		if err := scope.Raw(query).Exec().DB().Error; err != nil {
+			return count, err
+		}
+		count += scope.DB().RowsAffected
+	}
+	return count, nil
+}
+
+// bulkInsertSQL builds the multi-row
+// INSERT statement of 'rows' and adds
+// their values to the vars of 'scope'.
+func bulkInsertSQL(scope *gorm.Scope, dialect, table string, fields []*gorm.Field, columns []int, rows [][]*gorm.Field, conflict *OnConflict) (string, error) {
+	names := make([]string, len(columns))
+	for i, j := range columns {
+		names[i] = scope.Quote(fields[j].DBName)
+	}
+	values := make([]string, len(rows))
+	for r, row := range rows {
+		vars := make([]string, len(columns))
+		for i, j := range columns {
+			vars[i] = scope.AddToVars(row[j].Field.Interface())
+		}
+		values[r] = "(" + strings.Join(vars, ",") + ")"
+	}
+
+	verb := "INSERT INTO"
+	suffix := ""
+	if conflict != nil {
+		quote := func(cols []string) []string {
+			quoted := make([]string, len(cols))
+			for i, col := range cols {
+				quoted[i] = scope.Quote(col)
+			}
+			return quoted
+		}
+		switch dialect {
+		case "postgres", "sqlite3":
+			suffix = " ON CONFLICT"
+			if len(conflict.Columns) > 0 {
+				suffix += " (" + strings.Join(quote(conflict.Columns), ",") + ")"
+			}
+			if conflict.DoNothing || len(conflict.Update) == 0 {
+				suffix += " DO NOTHING"
+			} else {
+				sets := quote(conflict.Update)
+				for i, col := range sets {
+					sets[i] = col + " = EXCLUDED." + col
+				}
+				suffix += " DO UPDATE SET " + strings.Join(sets, ",")
+			}
+		case "mysql":
+			if conflict.DoNothing || len(conflict.Update) == 0 {
+				verb = "INSERT IGNORE INTO"
+			} else {
+				sets := quote(conflict.Update)
+				for i, col := range sets {
+					sets[i] = col + " = VALUES(" + col + ")"
+				}
+				suffix = " ON DUPLICATE KEY UPDATE " + strings.Join(sets, ",")
+			}
+		default:
+			return "", ErrUpsertUnsupported
+		}
+	}
+
+	return verb + " " + scope.Quote(table) +
+		" (" + strings.Join(names, ",") + ") VALUES " +
+		strings.Join(values, ",") + suffix, nil
+}
+
+// End-of-file
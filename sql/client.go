@@ -0,0 +1,245 @@
+package sql
+
+import (
+	// Native packages
+	"context"
+	stdsql "database/sql"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	// Third parties
+	"github.com/jinzhu/gorm"
+)
+
+// DefaultName is the name under
+// which NewSQLClient registers
+// the default client.
+const DefaultName = "default"
+
+// Client holds the connections to
+// a primary database and its read
+// replicas. Several clients can be
+// used at the same time for talking
+// to different databases.
+type Client struct {
+	primary  *gorm.DB
+	replicas []*gorm.DB
+	next     uint32
+	// pools holds the pools of the
+	// handles bound to a context, by
+	// handle of the primary and the
+	// replicas.
+	pools map[*gorm.DB]*ctxPool
+	// migrations holds the migrations
+	// registered for the client.
+	migrations *migrationSet
+
+	queryTimeout       time.Duration
+	slowQueryThreshold time.Duration
+}
+
+var (
+	// clients holds the registered
+	// clients by name.
+	clients    = map[string]*Client{}
+	clientsMux = &sync.RWMutex{}
+)
+
+// New creates a client connected
+// to the database and its replicas
+// based on the given configuration.
+func New(cfg Configs) (*Client, error) {
+	primary, err := open(cfg)
+	if err != nil {
+		return nil, err
+	}
+	c := &Client{
+		primary:            withModes(primary, cfg.SoftDelete, cfg.Audit),
+		pools:              map[*gorm.DB]*ctxPool{},
+		migrations:         newMigrationSet(),
+		queryTimeout:       cfg.QueryTimeout,
+		slowQueryThreshold: cfg.SlowQueryThreshold,
+	}
+	c.pools[c.primary] = newCtxPool(c.primary)
+	for _, replicaCfg := range cfg.Replicas {
+		replica, err := open(replicaConfigs(cfg, replicaCfg))
+		if err != nil {
+			_ = c.Close()
+			return nil, err
+		}
+		replica = withModes(replica, cfg.SoftDelete, cfg.Audit)
+		c.replicas = append(c.replicas, replica)
+		c.pools[replica] = newCtxPool(replica)
+	}
+	return c, nil
+}
+
+// replicaConfigs returns the
+// configuration of a replica, whose
+// empty fields take the values of
+// the primary, e.g. a replica only
+// needs its Host.
+func replicaConfigs(primary, replica Configs) Configs {
+	str := func(value *string, fallback string) {
+		if *value == "" {
+			*value = fallback
+		}
+	}
+	str(&replica.Driver, primary.Driver)
+	str(&replica.Host, primary.Host)
+	str(&replica.Port, primary.Port)
+	str(&replica.Username, primary.Username)
+	str(&replica.Password, primary.Password)
+	str(&replica.Database, primary.Database)
+	str(&replica.SSLMode, primary.SSLMode)
+	str(&replica.Charset, primary.Charset)
+	if replica.Params == nil {
+		replica.Params = primary.Params
+	}
+	if replica.MaxOpenConns == 0 {
+		replica.MaxOpenConns = primary.MaxOpenConns
+	}
+	if replica.MaxIdleConns == 0 {
+		replica.MaxIdleConns = primary.MaxIdleConns
+	}
+	if replica.ConnMaxLifetime == 0 {
+		replica.ConnMaxLifetime = primary.ConnMaxLifetime
+	}
+	return replica
+}
+
+// open opens the connection of 'cfg'
+// and tunes its pool.
+func open(cfg Configs) (*gorm.DB, error) {
+	connString, err := connectionString(cfg)
+	if err != nil {
+		return nil, err
+	}
+	switch sqlDB, err := gorm.Open(cfg.Driver, connString); {
+	case err != nil:
+		return nil, err
+	default:
+		pool := sqlDB.DB()
+		if cfg.MaxOpenConns > 0 {
+			pool.SetMaxOpenConns(cfg.MaxOpenConns)
+		}
+		if cfg.MaxIdleConns > 0 {
+			pool.SetMaxIdleConns(cfg.MaxIdleConns)
+		}
+		if cfg.ConnMaxLifetime > 0 {
+			pool.SetConnMaxLifetime(cfg.ConnMaxLifetime)
+		}
+		return sqlDB, nil
+	}
+}
+
+// Open creates a client based on
+// the given configuration and
+// registers it under 'name'.
+func Open(name string, cfg Configs) (*Client, error) {
+	c, err := New(cfg)
+	if err != nil {
+		return nil, err
+	}
+	Register(name, c)
+	return c, nil
+}
+
+// Register registers the client
+// under 'name', replacing the
+// current one if any.
+func Register(name string, c *Client) {
+	clientsMux.Lock()
+	clients[name] = c
+	clientsMux.Unlock()
+}
+
+// Get returns the client registered
+// under 'name', or nil if there is
+// none. The helpers of a nil client
+// return ErrInitialized.
+func Get(name string) *Client {
+	clientsMux.RLock()
+	defer clientsMux.RUnlock()
+	return clients[name]
+}
+
+// Close closes the connections
+// of the client and its replicas.
+func (c *Client) Close() error {
+	if c == nil || c.primary == nil {
+		return ErrInitialized
+	}
+	err := c.primary.Close()
+	for _, replica := range c.replicas {
+		if errReplica := replica.Close(); err == nil {
+			err = errReplica
+		}
+	}
+	return err
+}
+
+// Stats returns the statistics of
+// the connection pool of the primary
+// database. The zero value is
+// returned for a nil client.
+func (c *Client) Stats() stdsql.DBStats {
+	if c == nil || c.primary == nil {
+		return stdsql.DBStats{}
+	}
+	return c.primary.DB().Stats()
+}
+
+// writer returns the handle of
+// the primary database.
+func (c *Client) writer() *gorm.DB {
+	if c == nil {
+		return nil
+	}
+	return c.primary
+}
+
+// reader returns the handle of the
+// next replica in a round-robin way,
+// or the primary when there is none.
+func (c *Client) reader() *gorm.DB {
+	if c == nil {
+		return nil
+	}
+	if len(c.replicas) == 0 {
+		return c.primary
+	}
+	i := atomic.AddUint32(&c.next, 1)
+	return c.replicas[int(i)%len(c.replicas)]
+}
+
+// Find selects the records base
+// on the parameter 'condition' from
+// a replica, and pushes the data
+// into the parameter 'result'.
+func (c *Client) Find(table string, result interface{}, condition interface{}) error {
+	return c.FindCtx(context.Background(), table, result, condition)
+}
+
+// Update updates all the records
+// base on the parameter 'selector'
+// with data 'updater'.
+func (c *Client) Update(table string, updater, selector interface{}) error {
+	return c.UpdateCtx(context.Background(), table, updater, selector)
+}
+
+// Delete removes all the records
+// which satisfied the 'selector'.
+func (c *Client) Delete(table string, selector interface{}) error {
+	return c.DeleteCtx(context.Background(), table, selector)
+}
+
+// Insert creates all the records
+// in the 'data', a pointer of a
+// slice or a pointer of a struct.
+func (c *Client) Insert(table string, data interface{}) error {
+	return c.InsertCtx(context.Background(), table, data)
+}
+
+// End-of-file
@@ -0,0 +1,274 @@
+package sql
+
+import (
+	// Native packages
+	"context"
+	stdsql "database/sql"
+	"reflect"
+	"sync"
+	"time"
+
+	// Third parties
+	"github.com/jinzhu/gorm"
+)
+
+// contextCommon is implemented by
+// both *sql.DB and *sql.Tx.
+type contextCommon interface {
+	ExecContext(ctx context.Context, query string, args ...interface{}) (stdsql.Result, error)
+	PrepareContext(ctx context.Context, query string) (*stdsql.Stmt, error)
+	QueryContext(ctx context.Context, query string, args ...interface{}) (*stdsql.Rows, error)
+	QueryRowContext(ctx context.Context, query string, args ...interface{}) *stdsql.Row
+}
+
+// ctxCommon satisfies gorm.SQLCommon
+// by running every query with 'ctx',
+// so the query is canceled when
+// 'ctx' is done. 'ctx' is set for
+// each operation by ctxPool.
+type ctxCommon struct {
+	ctx context.Context
+	db  contextCommon
+}
+
+func (c *ctxCommon) Exec(query string, args ...interface{}) (stdsql.Result, error) {
+	return c.db.ExecContext(c.ctx, query, args...)
+}
+
+func (c *ctxCommon) Prepare(query string) (*stdsql.Stmt, error) {
+	return c.db.PrepareContext(c.ctx, query)
+}
+
+func (c *ctxCommon) Query(query string, args ...interface{}) (*stdsql.Rows, error) {
+	return c.db.QueryContext(c.ctx, query, args...)
+}
+
+func (c *ctxCommon) QueryRow(query string, args ...interface{}) *stdsql.Row {
+	return c.db.QueryRowContext(c.ctx, query, args...)
+}
+
+// ctxHandle is a handle on the
+// connection of a ctxPool, whose
+// queries run with the context of
+// the operation holding it.
+type ctxHandle struct {
+	db     *gorm.DB
+	common *ctxCommon
+}
+
+// ctxPool reuses the handles bound
+// to a context on the connection of
+// 'handle', so that a gorm handle is
+// opened once per concurrent
+// operation rather than for every
+// operation. The handles are opened
+// with the settings of 'handle'.
+type ctxPool struct {
+	handle *gorm.DB
+	pool   sync.Pool
+}
+
+func newCtxPool(handle *gorm.DB) *ctxPool {
+	return &ctxPool{handle: handle}
+}
+
+// get returns a handle on the same
+// connection as the pool which runs
+// its queries with 'ctx' and stamps
+// the actor of 'ctx', and the function
+// releasing it once the operation is
+// over.
+func (p *ctxPool) get(ctx context.Context) (*gorm.DB, func(), error) {
+	// No need to wrap a context
+	// which can never be canceled.
+	common, ok := p.handle.CommonDB().(contextCommon)
+	if ctx.Done() == nil || !ok {
+		return copySettings(ctx, p.handle, p.handle), func() {}, nil
+	}
+	h, _ := p.pool.Get().(*ctxHandle)
+	if h == nil {
+		c := &ctxCommon{db: common}
+		db, err := gorm.Open(p.handle.Dialect().GetName(), c)
+		if err != nil {
+			return nil, nil, err
+		}
+		h = &ctxHandle{db: copySettings(context.Background(), p.handle, db), common: c}
+	}
+	h.common.ctx = ctx
+	return copySettings(ctx, h.db, h.db), func() {
+		h.common.ctx = nil
+		p.pool.Put(h)
+	}, nil
+}
+
+// pool returns the pool of the
+// handles bound to a context on
+// the connection of 'handle'.
+func (c *Client) pool(handle *gorm.DB) *ctxPool {
+	if p, ok := c.pools[handle]; ok {
+		return p
+	}
+	return newCtxPool(handle)
+}
+
+// run runs 'fn' with a context
+// bound to 'ctx'. The default query
+// timeout applies when 'ctx' has no
+// deadline, and the operations
+// slower than the threshold are
+// logged with the request ID.
+func (c *Client) run(ctx context.Context, handle *gorm.DB, op, table string, fn func(ctx context.Context) error) error {
+	if handle == nil {
+		return ErrInitialized
+	}
+	if _, ok := ctx.Deadline(); !ok && c.queryTimeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, c.queryTimeout)
+		defer cancel()
+	}
+
+	start := time.Now()
+	err := fn(ctx)
+	if elapsed := time.Since(start); c.slowQueryThreshold > 0 && elapsed >= c.slowQueryThreshold {
+		log.TWarnf(ctx, "Slow %s on table %s took %v", op, table, elapsed)
+	}
+	return err
+}
+
+// query is the same as run, but
+// 'fn' runs with a handle on
+// 'handle' bound to the context.
+func (c *Client) query(ctx context.Context, handle *gorm.DB, op, table string, fn func(h *gorm.DB) error) error {
+	return c.run(ctx, handle, op, table, func(ctx context.Context) error {
+		h, release, err := c.pool(handle).get(ctx)
+		if err != nil {
+			return err
+		}
+		defer release()
+		return fn(h)
+	})
+}
+
+// FindCtx is the same as Find, but
+// the query is canceled when 'ctx'
+// is done.
+func FindCtx(ctx context.Context, table string, result interface{}, condition interface{}) error {
+	return defaultClient.FindCtx(ctx, table, result, condition)
+}
+
+// FindWithOptionsCtx is the same as
+// FindWithOptions, but the queries
+// are canceled when 'ctx' is done.
+func FindWithOptionsCtx(ctx context.Context, table string, result, condition interface{}, opts QueryOptions) (int64, error) {
+	return defaultClient.FindWithOptionsCtx(ctx, table, result, condition, opts)
+}
+
+// UpdateCtx is the same as Update,
+// but the query is canceled when
+// 'ctx' is done.
+func UpdateCtx(ctx context.Context, table string, updater, selector interface{}) error {
+	return defaultClient.UpdateCtx(ctx, table, updater, selector)
+}
+
+// DeleteCtx is the same as Delete,
+// but the query is canceled when
+// 'ctx' is done.
+func DeleteCtx(ctx context.Context, table string, selector interface{}) error {
+	return defaultClient.DeleteCtx(ctx, table, selector)
+}
+
+// InsertCtx is the same as Insert,
+// but the queries are canceled (and
+// the transaction of a slice is
+// rolled back) when 'ctx' is done.
+func InsertCtx(ctx context.Context, table string, data interface{}) error {
+	return defaultClient.InsertCtx(ctx, table, data)
+}
+
+// BulkInsertCtx is the same as
+// BulkInsert, but the queries are
+// canceled and the transaction is
+// rolled back when 'ctx' is done.
+func BulkInsertCtx(ctx context.Context, table string, data interface{}, opts BulkOptions) (int64, error) {
+	return defaultClient.BulkInsertCtx(ctx, table, data, opts)
+}
+
+// FindCtx is the same as Find, but
+// the query is canceled when 'ctx'
+// is done.
+func (c *Client) FindCtx(ctx context.Context, table string, result interface{}, condition interface{}) error {
+	return c.query(ctx, c.reader(), "find", table, func(h *gorm.DB) error {
+		return find(h, table, result, condition)
+	})
+}
+
+// FindWithOptionsCtx is the same as
+// FindWithOptions, but the queries
+// are canceled when 'ctx' is done.
+func (c *Client) FindWithOptionsCtx(ctx context.Context, table string, result, condition interface{}, opts QueryOptions) (int64, error) {
+	var total int64
+	err := c.query(ctx, c.reader(), "find", table, func(h *gorm.DB) error {
+		var err error
+		total, err = findWithOptions(h, table, result, condition, opts)
+		return err
+	})
+	return total, err
+}
+
+// UpdateCtx is the same as Update,
+// but the query is canceled when
+// 'ctx' is done.
+func (c *Client) UpdateCtx(ctx context.Context, table string, updater, selector interface{}) error {
+	return c.query(ctx, c.writer(), "update", table, func(h *gorm.DB) error {
+		return update(h, table, updater, selector)
+	})
+}
+
+// DeleteCtx is the same as Delete,
+// but the query is canceled when
+// 'ctx' is done.
+func (c *Client) DeleteCtx(ctx context.Context, table string, selector interface{}) error {
+	return c.query(ctx, c.writer(), "delete", table, func(h *gorm.DB) error {
+		return remove(h, table, selector)
+	})
+}
+
+// InsertCtx is the same as Insert,
+// but the queries are canceled (and
+// the transaction of a slice is
+// rolled back) when 'ctx' is done.
+func (c *Client) InsertCtx(ctx context.Context, table string, data interface{}) error {
+	// Records of a slice, and records
+	// stamped in audit mode with more
+	// than one statement, are created
+	// inside a transaction.
+	slice := reflect.TypeOf(data).Kind() == reflect.Ptr && reflect.ValueOf(data).Elem().Kind() == reflect.Slice
+	if w := c.writer(); slice || (w != nil && audited(w)) {
+		return c.run(ctx, c.writer(), "insert", table, func(ctx context.Context) error {
+			return withTransaction(ctx, c.writer(), func(tx *Tx) error {
+				return insert(tx.db, table, data)
+			})
+		})
+	}
+	return c.query(ctx, c.writer(), "insert", table, func(h *gorm.DB) error {
+		return insert(h, table, data)
+	})
+}
+
+// BulkInsertCtx is the same as
+// BulkInsert, but the queries are
+// canceled and the transaction is
+// rolled back when 'ctx' is done.
+func (c *Client) BulkInsertCtx(ctx context.Context, table string, data interface{}, opts BulkOptions) (int64, error) {
+	var count int64
+	err := c.run(ctx, c.writer(), "bulk insert", table, func(ctx context.Context) error {
+		return withTransaction(ctx, c.writer(), func(tx *Tx) error {
+			n, err := bulkInsert(tx.db, table, data, opts)
+			count = n
+			return err
+		})
+	})
+	return count, err
+}
+
+// End-of-file
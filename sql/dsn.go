@@ -0,0 +1,157 @@
+package sql
+
+import (
+	// Native packages
+	"fmt"
+	"net"
+	"net/url"
+	"sort"
+	"strings"
+)
+
+// DSNBuilder builds the connection
+// string of a driver based on the
+// configuration of the connection.
+type DSNBuilder func(cfg Configs) string
+
+// dsnBuilders holds the builders of
+// connection strings by driver name.
+var dsnBuilders = map[string]DSNBuilder{
+	"mssql":    mssqlDSN,
+	"postgres": postgresDSN,
+	"mysql":    mysqlDSN,
+	"sqlite3":  sqliteDSN,
+}
+
+// RegisterDSNBuilder registers the
+// builder of connection strings for
+// 'driver', replacing the current
+// one if any. It should be called
+// before NewSQLClient (typically in
+// an init function).
+func RegisterDSNBuilder(driver string, builder DSNBuilder) {
+	dsnBuilders[driver] = builder
+}
+
+// connectionString builds the
+// connection string of 'cfg' with
+// the builder of its driver.
+func connectionString(cfg Configs) (string, error) {
+	builder, ok := dsnBuilders[cfg.Driver]
+	if !ok {
+		return "", ErrUnsupportedDriver
+	}
+	return builder(cfg), nil
+}
+
+// mssqlDSN builds the connection
+// string of Microsoft SQL server in
+// the URL form, which escapes the
+// values, and Host may carry an
+// instance as "host\instance".
+// SSLMode is passed as 'encrypt'.
+func mssqlDSN(cfg Configs) string {
+	host, instance := cfg.Host, ""
+	if i := strings.Index(host, `\`); i >= 0 {
+		host, instance = host[:i], host[i+1:]
+	}
+	if cfg.Port != "" {
+		host = net.JoinHostPort(host, cfg.Port)
+	}
+	params := url.Values{}
+	if cfg.Database != "" {
+		params.Set("database", cfg.Database)
+	}
+	if cfg.SSLMode != "" {
+		params.Set("encrypt", cfg.SSLMode)
+	}
+	for key, value := range cfg.Params {
+		params.Set(key, value)
+	}
+	u := url.URL{
+		Scheme:   "sqlserver",
+		User:     url.UserPassword(cfg.Username, cfg.Password),
+		Host:     host,
+		RawQuery: params.Encode(),
+	}
+	if instance != "" {
+		u.Path = "/" + instance
+	}
+	return u.String()
+}
+
+// postgresDSN builds the connection
+// string of PostgreSQL server.
+func postgresDSN(cfg Configs) string {
+	pairs := []string{}
+	add := func(key, value string) {
+		if value != "" {
+			value = strings.Replace(value, `\`, `\\`, -1)
+			value = strings.Replace(value, `'`, `\'`, -1)
+			pairs = append(pairs, key+"='"+value+"'")
+		}
+	}
+	add("host", cfg.Host)
+	add("port", cfg.Port)
+	add("user", cfg.Username)
+	add("password", cfg.Password)
+	add("dbname", cfg.Database)
+	add("sslmode", cfg.SSLMode)
+	if cfg.Charset != "" {
+		add("client_encoding", cfg.Charset)
+	}
+	for _, key := range sortedKeys(cfg.Params) {
+		add(key, cfg.Params[key])
+	}
+	return strings.Join(pairs, " ")
+}
+
+// mysqlDSN builds the connection
+// string of MySQL server. Times
+// are parsed into time.Time unless
+// 'parseTime' is set in Params.
+// SSLMode is passed as 'tls'.
+func mysqlDSN(cfg Configs) string {
+	addr := cfg.Host
+	if cfg.Port != "" {
+		addr += ":" + cfg.Port
+	}
+	params := url.Values{}
+	params.Set("parseTime", "true")
+	if cfg.Charset != "" {
+		params.Set("charset", cfg.Charset)
+	}
+	if cfg.SSLMode != "" {
+		params.Set("tls", cfg.SSLMode)
+	}
+	for key, value := range cfg.Params {
+		params.Set(key, value)
+	}
+	return fmt.Sprintf("%s:%s@tcp(%s)/%s?%s", cfg.Username, cfg.Password, addr, cfg.Database, params.Encode())
+}
+
+// sqliteDSN builds the connection
+// string of SQLite, where Database
+// is the path of the database file
+// (or ":memory:").
+func sqliteDSN(cfg Configs) string {
+	if len(cfg.Params) == 0 {
+		return cfg.Database
+	}
+	params := url.Values{}
+	for key, value := range cfg.Params {
+		params.Set(key, value)
+	}
+	return "file:" + cfg.Database + "?" + params.Encode()
+}
+
+func sortedKeys(m map[string]string) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// End-of-file
@@ -0,0 +1,500 @@
+package sql
+
+import (
+	// Native packages
+	"context"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"path"
+	"regexp"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+
+	// Third parties
+	"github.com/jinzhu/gorm"
+)
+
+const (
+	migrationsTable     = "schema_migrations"
+	migrationsLockTable = "schema_migrations_lock"
+
+	// migrationLockStale is the age from
+	// which a lock is considered left by
+	// a crashed instance and is taken
+	// over. The lock is refreshed every
+	// migrationLockRefresh while held.
+	migrationLockStale   = 5 * time.Minute
+	migrationLockRefresh = time.Minute
+	migrationLockRetry   = time.Second
+)
+
+// Migration is a versioned change of
+// the schema. Migrations are applied
+// in the ascending order of versions,
+// each one inside a transaction.
+// (Notice: Some servers like MySQL
+// commit the DDL statements implicitly,
+// so they cannot be rolled back.)
+type Migration struct {
+	Version int64
+	Name    string
+	Up      func(tx *Tx) error
+	Down    func(tx *Tx) error
+}
+
+// MigrationStatus contains the
+// state of a migration.
+type MigrationStatus struct {
+	Version   int64
+	Name      string
+	Applied   bool
+	AppliedAt time.Time
+}
+
+// schemaMigration is the record of
+// an applied migration in the
+// bookkeeping table.
+type schemaMigration struct {
+	Version   int64 `gorm:"primary_key;auto_increment:false"`
+	Name      string
+	AppliedAt time.Time
+}
+
+func (schemaMigration) TableName() string {
+	return migrationsTable
+}
+
+// schemaMigrationLock is the only
+// record of the lock table, held by
+// the instance running migrations.
+type schemaMigrationLock struct {
+	ID       int `gorm:"primary_key;auto_increment:false"`
+	Owner    string
+	LockedAt time.Time
+}
+
+func (schemaMigrationLock) TableName() string {
+	return migrationsLockTable
+}
+
+// migrationSet holds the migrations
+// registered for a client.
+type migrationSet struct {
+	mux        sync.Mutex
+	migrations map[int64]Migration
+}
+
+func newMigrationSet() *migrationSet {
+	return &migrationSet{migrations: map[int64]Migration{}}
+}
+
+var (
+	// defaultMigrations holds the
+	// migrations of the default client,
+	// registered by the package-level
+	// functions.
+	defaultMigrations = newMigrationSet()
+
+	// migrationFileName matches the
+	// names of migration files, e.g.
+	// 0001_create_users.up.sql.
+	migrationFileName = regexp.MustCompile(`^(\d+)_(.+)\.(up|down)\.sql$`)
+)
+
+// RegisterMigrations registers the
+// migrations run by Migrate and
+// Rollback on the default client.
+// It returns an error, and registers
+// none of them, when a version is
+// registered twice.
+func RegisterMigrations(ms ...Migration) error {
+	return defaultMigrations.register(ms...)
+}
+
+// RegisterMigrations registers the
+// migrations run by Migrate and
+// Rollback of the client.
+func (c *Client) RegisterMigrations(ms ...Migration) error {
+	if c == nil {
+		return ErrInitialized
+	}
+	return c.migrations.register(ms...)
+}
+
+func (s *migrationSet) register(ms ...Migration) error {
+	s.mux.Lock()
+	defer s.mux.Unlock()
+	versions := make(map[int64]bool, len(ms))
+	for _, m := range ms {
+		if _, ok := s.migrations[m.Version]; ok || versions[m.Version] {
+			return fmt.Errorf("migration %d: %v", m.Version, ErrDuplicateMigration)
+		}
+		versions[m.Version] = true
+	}
+	for _, m := range ms {
+		s.migrations[m.Version] = m
+	}
+	return nil
+}
+
+// LoadMigrations registers the
+// migrations from the .sql files in
+// the directory 'dir' of 'fs'. The
+// files are named after the version,
+// the name and the direction of the
+// migration, e.g. 0001_create_users.up.sql
+// and 0001_create_users.down.sql.
+// 'fs' can be the local file system
+// (http.Dir) or files embedded in the
+// binary by any tool providing an
+// http.FileSystem.
+// (Notice: Each file is executed as
+// one statement, so MySQL requires
+// the parameter multiStatements=true
+// for files with several statements.)
+// The migrations are registered on
+// the default client.
+func LoadMigrations(fs http.FileSystem, dir string) error {
+	return defaultMigrations.load(fs, dir)
+}
+
+// LoadMigrations registers the
+// migrations of the client from the
+// .sql files in the directory 'dir'
+// of 'fs'.
+func (c *Client) LoadMigrations(fs http.FileSystem, dir string) error {
+	if c == nil {
+		return ErrInitialized
+	}
+	return c.migrations.load(fs, dir)
+}
+
+func (s *migrationSet) load(fs http.FileSystem, dir string) error {
+	d, err := fs.Open(dir)
+	if err != nil {
+		return err
+	}
+	infos, err := d.Readdir(-1)
+	_ = d.Close()
+	if err != nil {
+		return err
+	}
+
+	loaded := map[int64]*Migration{}
+	for _, info := range infos {
+		match := migrationFileName.FindStringSubmatch(info.Name())
+		if info.IsDir() || match == nil {
+			continue
+		}
+		version, err := strconv.ParseInt(match[1], 10, 64)
+		if err != nil {
+			return err
+		}
+		script, err := readFile(fs, path.Join(dir, info.Name()))
+		if err != nil {
+			return err
+		}
+		m, ok := loaded[version]
+		if !ok {
+			m = &Migration{Version: version, Name: match[2]}
+			loaded[version] = m
+		}
+		if match[3] == "up" {
+			m.Up = execScript(script)
+		} else {
+			m.Down = execScript(script)
+		}
+	}
+
+	ms := make([]Migration, 0, len(loaded))
+	for _, m := range loaded {
+		ms = append(ms, *m)
+	}
+	return s.register(ms...)
+}
+
+func readFile(fs http.FileSystem, name string) (string, error) {
+	f, err := fs.Open(name)
+	if err != nil {
+		return "", err
+	}
+	defer func() { _ = f.Close() }()
+	b, err := ioutil.ReadAll(f)
+	return string(b), err
+}
+
+func execScript(script string) func(tx *Tx) error {
+	return func(tx *Tx) error {
+		return tx.Exec(script)
+	}
+}
+
+// sorted returns the registered
+// migrations in the ascending
+// order of versions.
+func (s *migrationSet) sorted() []Migration {
+	s.mux.Lock()
+	defer s.mux.Unlock()
+	ms := make([]Migration, 0, len(s.migrations))
+	for _, m := range s.migrations {
+		ms = append(ms, m)
+	}
+	sort.Slice(ms, func(i, j int) bool { return ms[i].Version < ms[j].Version })
+	return ms
+}
+
+// Migrate applies all the registered
+// migrations which have not been
+// applied yet, in the ascending order
+// of versions. Only one instance can
+// migrate at a time, the others wait
+// until it finishes or 'ctx' is done.
+func Migrate(ctx context.Context) error {
+	return defaultClient.Migrate(ctx)
+}
+
+// Rollback reverts the last 'n'
+// applied migrations, in the
+// descending order of versions.
+// 'n' must be positive.
+func Rollback(ctx context.Context, n int) error {
+	return defaultClient.Rollback(ctx, n)
+}
+
+// Status returns the state of all
+// the registered and applied
+// migrations, in the ascending
+// order of versions.
+func Status() ([]MigrationStatus, error) {
+	return defaultClient.Status()
+}
+
+// Migrate applies the migrations
+// registered for the client on its
+// primary database.
+func (c *Client) Migrate(ctx context.Context) error {
+	return migrate(ctx, c.writer(), c.migrationSet())
+}
+
+// Rollback reverts the last 'n'
+// applied migrations on the primary
+// database of the client.
+func (c *Client) Rollback(ctx context.Context, n int) error {
+	return rollback(ctx, c.writer(), c.migrationSet(), n)
+}
+
+// Status returns the state of the
+// migrations on the primary database
+// of the client.
+func (c *Client) Status() ([]MigrationStatus, error) {
+	return status(c.writer(), c.migrationSet())
+}
+
+func (c *Client) migrationSet() *migrationSet {
+	if c == nil {
+		return nil
+	}
+	return c.migrations
+}
+
+func migrate(ctx context.Context, handle *gorm.DB, set *migrationSet) error {
+	if handle == nil {
+		return ErrInitialized
+	}
+	unlock, err := lockMigrations(ctx, handle)
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	applied, err := appliedMigrations(handle)
+	if err != nil {
+		return err
+	}
+	for _, m := range set.sorted() {
+		if _, ok := applied[m.Version]; ok {
+			continue
+		}
+		if m.Up == nil {
+			return fmt.Errorf("migration %d: %v", m.Version, ErrIrreversibleMigration)
+		}
+		err := withTransaction(ctx, handle, func(tx *Tx) error {
+			if err := m.Up(tx); err != nil {
+				return err
+			}
+			return tx.db.Create(&schemaMigration{Version: m.Version, Name: m.Name, AppliedAt: time.Now()}).Error
+		})
+		if err != nil {
+			return fmt.Errorf("migration %d %s: %v", m.Version, m.Name, err)
+		}
+	}
+	return nil
+}
+
+func rollback(ctx context.Context, handle *gorm.DB, set *migrationSet, n int) error {
+	if handle == nil {
+		return ErrInitialized
+	}
+	// A negative limit is left out
+	// by gorm, which would revert
+	// all the migrations.
+	if n <= 0 {
+		return ErrInvalidRollback
+	}
+	unlock, err := lockMigrations(ctx, handle)
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	var applied []schemaMigration
+	if err := handle.Order("version desc").Limit(n).Find(&applied).Error; err != nil {
+		return err
+	}
+	registered := make(map[int64]Migration)
+	for _, m := range set.sorted() {
+		registered[m.Version] = m
+	}
+
+	for _, a := range applied {
+		m, ok := registered[a.Version]
+		if !ok || m.Down == nil {
+			return fmt.Errorf("migration %d: %v", a.Version, ErrIrreversibleMigration)
+		}
+		err := withTransaction(ctx, handle, func(tx *Tx) error {
+			if err := m.Down(tx); err != nil {
+				return err
+			}
+			return tx.db.Delete(&schemaMigration{Version: a.Version}).Error
+		})
+		if err != nil {
+			return fmt.Errorf("migration %d %s: %v", m.Version, m.Name, err)
+		}
+	}
+	return nil
+}
+
+func status(handle *gorm.DB, set *migrationSet) ([]MigrationStatus, error) {
+	if handle == nil {
+		return nil, ErrInitialized
+	}
+	if err := createTables(handle, &schemaMigration{}); err != nil {
+		return nil, err
+	}
+	applied, err := appliedMigrations(handle)
+	if err != nil {
+		return nil, err
+	}
+
+	statuses := []MigrationStatus{}
+	for _, m := range set.sorted() {
+		s := MigrationStatus{Version: m.Version, Name: m.Name}
+		if a, ok := applied[m.Version]; ok {
+			s.Applied = true
+			s.AppliedAt = a.AppliedAt
+			delete(applied, m.Version)
+		}
+		statuses = append(statuses, s)
+	}
+	// Applied migrations which are
+	// not registered anymore.
+	for _, a := range applied {
+		statuses = append(statuses, MigrationStatus{Version: a.Version, Name: a.Name, Applied: true, AppliedAt: a.AppliedAt})
+	}
+	sort.Slice(statuses, func(i, j int) bool { return statuses[i].Version < statuses[j].Version })
+	return statuses, nil
+}
+
+func appliedMigrations(handle *gorm.DB) (map[int64]schemaMigration, error) {
+	var records []schemaMigration
+	if err := handle.Find(&records).Error; err != nil {
+		return nil, err
+	}
+	applied := make(map[int64]schemaMigration, len(records))
+	for _, r := range records {
+		applied[r.Version] = r
+	}
+	return applied, nil
+}
+
+// createTables creates the tables
+// of 'models' if needed. The replicas
+// starting together race on creating
+// them, and the losers fail (e.g. with
+// a duplicate relation on postgres),
+// so the creation succeeds when the
+// tables exist afterwards, and is
+// retried once otherwise.
+func createTables(handle *gorm.DB, models ...interface{}) error {
+	err := handle.AutoMigrate(models...).Error
+	if err == nil {
+		return nil
+	}
+	for _, model := range models {
+		if !handle.HasTable(model) {
+			return handle.AutoMigrate(models...).Error
+		}
+	}
+	return nil
+}
+
+// lockMigrations creates the
+// bookkeeping tables if needed and
+// takes the lock of migrations,
+// waiting until it is released by
+// other instances or 'ctx' is done.
+// The returned function releases
+// the lock.
+func lockMigrations(ctx context.Context, handle *gorm.DB) (func(), error) {
+	if err := createTables(handle, &schemaMigration{}, &schemaMigrationLock{}); err != nil {
+		return nil, err
+	}
+	hostname, _ := os.Hostname()
+	owner := fmt.Sprintf("%s-%d-%d", hostname, os.Getpid(), time.Now().UnixNano())
+
+	for {
+		// Take over the lock left
+		// by a crashed instance.
+		handle.Where("id = ? AND locked_at < ?", 1, time.Now().Add(-migrationLockStale)).Delete(&schemaMigrationLock{})
+
+		err := handle.Create(&schemaMigrationLock{ID: 1, Owner: owner, LockedAt: time.Now()}).Error
+		if err == nil {
+			break
+		}
+		// The creation failed for
+		// another reason than the
+		// lock being held.
+		if handle.First(&schemaMigrationLock{}, 1).RecordNotFound() {
+			return nil, err
+		}
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-time.After(migrationLockRetry):
+		}
+	}
+
+	stop := make(chan struct{})
+	go func() {
+		ticker := time.NewTicker(migrationLockRefresh)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-stop:
+				return
+			case <-ticker.C:
+				handle.Model(&schemaMigrationLock{}).Where("id = ? AND owner = ?", 1, owner).UpdateColumn("locked_at", time.Now())
+			}
+		}
+	}()
+	return func() {
+		close(stop)
+		handle.Where("id = ? AND owner = ?", 1, owner).Delete(&schemaMigrationLock{})
+	}, nil
+}
+
+// End-of-file
@@ -0,0 +1,131 @@
+package sql
+
+import (
+	// Native packages
+	"context"
+	"reflect"
+
+	// Third parties
+	"github.com/jinzhu/gorm"
+)
+
+// QueryOptions contains the options
+// for selecting a page of records.
+type QueryOptions struct {
+	// Select contains the columns
+	// to select. Empty Select means
+	// all the columns.
+	Select []string
+	// Order contains the ORDER BY
+	// clauses, e.g. "created_at desc".
+	// (Notice: They are written into
+	// the query as they are, never
+	// put user input in them.)
+	Order []string
+	// Limit is the maximum number of
+	// records. Zero means no limit.
+	Limit int
+	// Offset is the number of records
+	// to skip before the page.
+	Offset int
+	// KeysetColumn enables the keyset
+	// pagination on this column, which
+	// is also the first ORDER BY clause.
+	// It should be unique (e.g. "id").
+	KeysetColumn string
+	// After is the value of KeysetColumn
+	// in the last record of the previous
+	// page. Nil means the first page.
+	After interface{}
+	// Desc sorts the keyset pagination
+	// in the descending order.
+	Desc bool
+	// Count makes FindWithOptions count
+	// the total number of records which
+	// satisfied the condition.
+	Count bool
+	// WithDeleted includes the soft
+	// deleted records in the result.
+	WithDeleted bool
+}
+
+// FindWithOptions selects the records
+// base on the parameter 'condition'
+// and the options 'opts', and pushes
+// the data into the parameter 'result'.
+// When opts.Count is set, the total
+// number of records which satisfied
+// the 'condition' is returned,
+// regardless of the pagination.
+func FindWithOptions(table string, result, condition interface{}, opts QueryOptions) (int64, error) {
+	return defaultClient.FindWithOptions(table, result, condition, opts)
+}
+
+// FindWithOptions selects the records
+// base on the parameter 'condition'
+// and the options 'opts' from a
+// replica of the client.
+func (c *Client) FindWithOptions(table string, result, condition interface{}, opts QueryOptions) (int64, error) {
+	return c.FindWithOptionsCtx(context.Background(), table, result, condition, opts)
+}
+
+// FindWithOptions selects the records
+// base on the parameter 'condition'
+// and the options 'opts' inside the
+// transaction.
+func (tx *Tx) FindWithOptions(table string, result, condition interface{}, opts QueryOptions) (int64, error) {
+	return findWithOptions(tx.db, table, result, condition, opts)
+}
+
+func findWithOptions(handle *gorm.DB, table string, result, condition interface{}, opts QueryOptions) (int64, error) {
+	if t := reflect.TypeOf(result).Kind(); t != reflect.Ptr {
+		return 0, ErrNotSliceOrStructPtr
+	}
+	q := handle.Table(table)
+	switch {
+	case opts.WithDeleted:
+		// Also disables the scope of
+		// gorm for models with a field
+		// DeletedAt.
+		q = q.Unscoped()
+	case softDeleted(handle):
+		q = notDeleted(q)
+	}
+	if condition != nil {
+		q = q.Where(condition)
+	}
+
+	var total int64
+	if opts.Count {
+		if err := q.Count(&total).Error; err != nil {
+			return 0, err
+		}
+	}
+
+	if len(opts.Select) > 0 {
+		q = q.Select(opts.Select)
+	}
+	if opts.KeysetColumn != "" {
+		column := q.NewScope(nil).Quote(opts.KeysetColumn)
+		op, dir := " > ?", " ASC"
+		if opts.Desc {
+			op, dir = " < ?", " DESC"
+		}
+		if opts.After != nil {
+			q = q.Where(column+op, opts.After)
+		}
+		q = q.Order(column + dir)
+	}
+	for _, order := range opts.Order {
+		q = q.Order(order)
+	}
+	if opts.Limit > 0 {
+		q = q.Limit(opts.Limit)
+	}
+	if opts.Offset > 0 {
+		q = q.Offset(opts.Offset)
+	}
+	return total, q.Find(result).Error
+}
+
+// End-of-file
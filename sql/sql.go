@@ -1,22 +1,27 @@
 package sql
 
 import (
+	// Native packages
+	"context"
+	stdsql "database/sql"
 	"errors"
 	"reflect"
-
-	// Native packages
-	"fmt"
+	"time"
 
 	// Third parties
 	"github.com/jinzhu/gorm"
-)
 
-const (
-	sqlConnectionStringFormat = "server=%s;user id=%s;password=%s;port=%s;database=%s"
+	// Internal packages
+	"github.com/tinwoan-go/basic-api/tlog"
 )
 
 var (
-	db *gorm.DB
+	// defaultClient holds the client
+	// created by NewSQLClient and is
+	// used by the package-level helpers.
+	defaultClient *Client
+
+	log tlog.Logger
 
 	// ErrNotSliceOrStructPtr is returned
 	// when the parameter is not a pointer
@@ -27,17 +32,128 @@ var (
 	// the parameter is nil to avoid delete
 	// all records.
 	ErrNoSelector = errors.New("no selector")
+
+	// ErrInitialized is returned when
+	// the connection to SQL server has
+	// not been initialized.
+	ErrInitialized = errors.New("SQL connection has not been initialized")
+
+	// ErrUnsupportedDriver is returned
+	// when there is no builder of the
+	// connection string for the driver.
+	ErrUnsupportedDriver = errors.New("unsupported SQL driver")
+
+	// ErrNotSlice is returned when
+	// the parameter is neither a slice
+	// or a pointer of a slice.
+	ErrNotSlice = errors.New("not a slice or a pointer of a slice")
+
+	// ErrUpsertUnsupported is returned
+	// when the server does not support
+	// the upsert of bulk inserts.
+	ErrUpsertUnsupported = errors.New("upsert is not supported by the SQL driver")
+
+	// ErrDuplicateMigration is returned
+	// when a version of migration is
+	// registered more than once.
+	ErrDuplicateMigration = errors.New("duplicate migration version")
+
+	// ErrIrreversibleMigration is returned
+	// when a migration has no function
+	// to apply or to revert it.
+	ErrIrreversibleMigration = errors.New("migration cannot be applied or reverted")
+
+	// ErrInvalidRollback is returned
+	// when the number of migrations
+	// to roll back is not positive.
+	ErrInvalidRollback = errors.New("number of migrations to roll back must be positive")
+
+	// ErrNoPrimaryKey is returned when
+	// the audit columns of a created
+	// record cannot be stamped because
+	// it has no primary key.
+	ErrNoPrimaryKey = errors.New("no primary key")
 )
 
+func init() {
+	log = tlog.WithPrefix("sql")
+}
+
 // Configs contains the configuration
 // for opening connection to SQL server.
 type Configs struct {
+	// Driver is one of mssql,
+	// postgres, mysql, sqlite3 or
+	// a driver registered with
+	// RegisterDSNBuilder.
 	Driver   string
 	Host     string
 	Port     string
 	Username string
 	Password string
+	// Database is the name of the
+	// database, or the path of the
+	// database file for sqlite3.
 	Database string
+	// SSLMode is the SSL mode of
+	// the connection, passed to the
+	// driver as it is (e.g. disable,
+	// require, verify-full for
+	// postgres; true, false,
+	// skip-verify for mysql).
+	SSLMode string
+	// Charset is the character
+	// set of the connection.
+	Charset string
+	// Params contains the extra
+	// connection parameters
+	// passed to the driver.
+	Params map[string]string
+	// MaxOpenConns is the maximum
+	// number of open connections.
+	// Zero means unlimited.
+	MaxOpenConns int
+	// MaxIdleConns is the maximum
+	// number of idle connections.
+	// Zero means the default of
+	// database/sql.
+	MaxIdleConns int
+	// ConnMaxLifetime is the maximum
+	// amount of time a connection
+	// may be reused. Zero means
+	// connections are reused forever.
+	ConnMaxLifetime time.Duration
+	// QueryTimeout is the default
+	// timeout of every operation
+	// whose context has no deadline.
+	// Zero means no timeout.
+	QueryTimeout time.Duration
+	// SlowQueryThreshold is the
+	// duration from which operations
+	// are logged as slow queries.
+	// Zero disables the logging.
+	SlowQueryThreshold time.Duration
+	// Replicas contains the read
+	// replicas of the database, where
+	// the read-only helpers (Find,
+	// FindWithOptions) are routed.
+	// The empty fields of a replica
+	// (other than its Replicas and
+	// modes) take the values of the
+	// primary.
+	Replicas []Configs
+	// SoftDelete makes Delete set the
+	// column deleted_at instead of
+	// removing the records, and Find
+	// filter out the deleted records.
+	SoftDelete bool
+	// Audit makes Insert stamp the
+	// columns created_at, updated_at
+	// and updated_by, and Update stamp
+	// updated_at and updated_by. The
+	// actor of updated_by is taken
+	// from the context (see WithActor).
+	Audit bool
 }
 
 // NewSQLClient creates a
@@ -47,23 +163,43 @@ type Configs struct {
 // Username, Password, Port,
 // Database name, and one of
 // the most important things
-// is the driver.)
+// is the driver, which selects
+// the format of the connection
+// string.)
+// (Notice: The driver itself
+// must be imported by your
+// application, e.g. with
+// "github.com/jinzhu/gorm/dialects/postgres".)
+// The created client becomes the
+// default client used by the
+// package-level helpers, and is
+// registered as "default".
 func NewSQLClient(cfg Configs) error {
-	connString := fmt.Sprintf(sqlConnectionStringFormat, cfg.Host, cfg.Username, cfg.Password, cfg.Port, cfg.Database)
-	switch sqlDB, err := gorm.Open(cfg.Driver, connString); {
-	case err != nil:
+	c, err := Open(DefaultName, cfg)
+	if err != nil {
 		return err
-	default:
-		db = sqlDB
-		return nil
 	}
+	c.migrations = defaultMigrations
+	defaultClient = c
+	return nil
+}
+
+// Stats returns the statistics of
+// the connection pool (connections
+// in use, idle, wait count and wait
+// duration, ...) for health checks
+// and metrics. The zero value is
+// returned when the connection has
+// not been initialized.
+func Stats() stdsql.DBStats {
+	return defaultClient.Stats()
 }
 
 // Close closes the connection
 // of SQL database, based on the
 // current instance in application.
 func Close() error {
-	return db.Close()
+	return defaultClient.Close()
 }
 
 // Find selects the records
@@ -72,13 +208,7 @@ func Close() error {
 // data into the parameter
 // 'result'.
 func Find(table string, result interface{}, condition interface{}) error {
-	if t := reflect.TypeOf(result).Kind(); t != reflect.Ptr {
-		return ErrNotSliceOrStructPtr
-	}
-	if condition != nil {
-		return db.Table(table).Where(condition).Find(result).Error
-	}
-	return db.Table(table).Find(result).Error
+	return FindCtx(context.Background(), table, result, condition)
 }
 
 // Update updates all the
@@ -89,41 +219,112 @@ func Find(table string, result interface{}, condition interface{}) error {
 // will updates all the records
 // in table with data 'updater'.
 func Update(table string, updater, selector interface{}) error {
-	if selector != nil {
-		return db.Table(table).Where(selector).UpdateColumns(updater).Error
-	}
-	return db.Table(table).UpdateColumns(updater).Error
+	return UpdateCtx(context.Background(), table, updater, selector)
 }
 
 // Delete removes all the records
 // which satisfied the 'selector'.
+// In soft delete mode the records
+// are marked as deleted instead.
 func Delete(table string, selector interface{}) error {
-	if selector != nil {
-		return db.Table(table).Delete(selector).Error
-	}
-	return ErrNoSelector
+	return DeleteCtx(context.Background(), table, selector)
 }
 
 // Insert accepts a pointer of
 // a slice or a pointer of a
 // struct. Insert creates all
 // the records in the 'data'.
+// The records of a slice are
+// created inside a transaction,
+// so either all or none of them
+// are created. They are created
+// one by one (one round trip per
+// record), so that their generated
+// keys are set and the gorm hooks
+// run. Use BulkInsert for large
+// imports with multi-row INSERT
+// statements.
 func Insert(table string, data interface{}) error {
+	return InsertCtx(context.Background(), table, data)
+}
+
+func find(handle *gorm.DB, table string, result interface{}, condition interface{}) error {
+	if t := reflect.TypeOf(result).Kind(); t != reflect.Ptr {
+		return ErrNotSliceOrStructPtr
+	}
+	q := handle.Table(table)
+	if softDeleted(handle) {
+		q = notDeleted(q)
+	}
+	if condition != nil {
+		return q.Where(condition).Find(result).Error
+	}
+	return q.Find(result).Error
+}
+
+func update(handle *gorm.DB, table string, updater, selector interface{}) error {
+	if stamps := auditColumns(handle, false); stamps != nil {
+		columns, err := columnsOf(handle, updater)
+		if err != nil {
+			return err
+		}
+		for column, value := range stamps {
+			columns[column] = value
+		}
+		updater = columns
+	}
+	if selector != nil {
+		return handle.Table(table).Where(selector).UpdateColumns(updater).Error
+	}
+	return handle.Table(table).UpdateColumns(updater).Error
+}
+
+func remove(handle *gorm.DB, table string, selector interface{}) error {
+	if selector == nil {
+		return ErrNoSelector
+	}
+	if softDeleted(handle) {
+		columns := auditColumns(handle, false)
+		if columns == nil {
+			columns = map[string]interface{}{}
+		}
+		columns[DeletedAtColumn] = time.Now()
+		return notDeleted(handle.Table(table)).Where(selector).UpdateColumns(columns).Error
+	}
+	return handle.Table(table).Delete(selector).Error
+}
+
+func insert(handle *gorm.DB, table string, data interface{}) error {
 	if t := reflect.TypeOf(data).Kind(); t != reflect.Ptr {
 		return ErrNotSliceOrStructPtr
 	}
 	v := reflect.ValueOf(data).Elem()
-	fmt.Printf("%+v\n", v)
 	if vt := v.Kind(); vt == reflect.Slice {
 		for i := 0; i < v.Len(); i++ {
-			if err := db.Table(table).Create(v.Index(i).Interface()).Error; err != nil {
+			// Create the records through
+			// their address, so that the
+			// generated keys are set.
+			record := v.Index(i)
+			if record.Kind() == reflect.Struct {
+				record = record.Addr()
+			}
+			if err := create(handle, table, record.Interface()); err != nil {
 				return err
 			}
 		}
 		return nil
 	}
 	if vt := v.Kind(); vt == reflect.Struct {
-		return db.Table(table).Create(v).Error
+		return create(handle, table, data)
 	}
 	return ErrNotSliceOrStructPtr
-}
\ No newline at end of file
+}
+
+func create(handle *gorm.DB, table string, data interface{}) error {
+	if audited(handle) {
+		return createAudited(handle, table, data)
+	}
+	return handle.Table(table).Create(data).Error
+}
+
+// End-of-file
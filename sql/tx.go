@@ -0,0 +1,105 @@
+package sql
+
+import (
+	// Native packages
+	"context"
+
+	// Third parties
+	"github.com/jinzhu/gorm"
+)
+
+// Tx is a transaction opened by
+// WithTransaction. It provides the
+// same helpers as the package, which
+// all run inside the transaction.
+type Tx struct {
+	db *gorm.DB
+}
+
+// WithTransaction runs 'fn' inside
+// a transaction. The transaction is
+// committed when 'fn' returns nil,
+// and rolled back when 'fn' returns
+// an error or panics (the panic is
+// propagated after the rollback).
+// The transaction is also rolled
+// back when 'ctx' is canceled.
+func WithTransaction(ctx context.Context, fn func(tx *Tx) error) error {
+	return defaultClient.WithTransaction(ctx, fn)
+}
+
+// WithTransaction runs 'fn' inside
+// a transaction on the primary
+// database of the client.
+func (c *Client) WithTransaction(ctx context.Context, fn func(tx *Tx) error) error {
+	return withTransaction(ctx, c.writer(), fn)
+}
+
+func withTransaction(ctx context.Context, handle *gorm.DB, fn func(tx *Tx) error) (err error) {
+	if handle == nil {
+		return ErrInitialized
+	}
+	t := handle.BeginTx(ctx, nil)
+	if t.Error != nil {
+		return t.Error
+	}
+	defer func() {
+		if r := recover(); r != nil {
+			t.Rollback()
+			panic(r)
+		}
+	}()
+	// The handle bound to 'ctx' is
+	// opened once per transaction.
+	h, release, err := newCtxPool(t).get(ctx)
+	if err != nil {
+		t.Rollback()
+		return err
+	}
+	defer release()
+	if err = fn(&Tx{db: h}); err != nil {
+		t.Rollback()
+		return err
+	}
+	return t.Commit().Error
+}
+
+// Find selects the records
+// base on the parameter
+// 'condition' and push the
+// data into the parameter
+// 'result'.
+func (tx *Tx) Find(table string, result interface{}, condition interface{}) error {
+	return find(tx.db, table, result, condition)
+}
+
+// Update updates all the
+// records base on the
+// parameter 'selector' for
+// condition with data 'updater'.
+func (tx *Tx) Update(table string, updater, selector interface{}) error {
+	return update(tx.db, table, updater, selector)
+}
+
+// Delete removes all the records
+// which satisfied the 'selector'.
+func (tx *Tx) Delete(table string, selector interface{}) error {
+	return remove(tx.db, table, selector)
+}
+
+// Insert accepts a pointer of
+// a slice or a pointer of a
+// struct. Insert creates all
+// the records in the 'data'.
+func (tx *Tx) Insert(table string, data interface{}) error {
+	return insert(tx.db, table, data)
+}
+
+// Exec executes the raw SQL
+// 'query' with the arguments
+// 'args' inside the transaction.
+func (tx *Tx) Exec(query string, args ...interface{}) error {
+	return tx.db.Exec(query, args...).Error
+}
+
+// End-of-file